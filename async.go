@@ -0,0 +1,155 @@
+package gioc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+// asyncKey identifies the future cached for a factory by IOCAsync
+type asyncKey struct {
+	fn uintptr
+}
+
+// Future is the result of a singleton being built in the background by IOCAsync
+type Future[T any] struct {
+	fnPtr uintptr
+	// ctx is the resolution context of the goroutine that created the future
+	ctx   context.Context
+	start sync.Once
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// IOCAsync starts building the singleton of fn in the background and returns a
+// Future for it. The future itself is cached, so every call for the same factory
+// returns the same future while the construction is in flight or once it succeeded.
+// A failed future is dropped from the cache so a later call can retry.
+//
+// The instance is built through IOC, so IOC(fn) called elsewhere waits for the
+// background construction instead of building a second instance. Panics raised by
+// the factory, including cycles and failing Initializer or Validator hooks, are
+// reported by Await as errors.
+//
+// The background construction keeps the values of the caller's resolution context,
+// so a factory started from IOCCtx sees them through ResolutionContext. Its
+// cancellation is not kept since the construction outlives the caller, but a context
+// that is already done returns a failed future without calling fn.
+//
+// Example:
+//
+//	func NewSearchService() *SearchService {
+//	    index := gioc.IOCAsync(LoadSearchIndex)
+//	    return &SearchService{index: index}
+//	}
+//
+//	func (s *SearchService) Search(ctx context.Context, q string) ([]Result, error) {
+//	    index, err := s.index.Await(ctx)
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    return index.Lookup(q), nil
+//	}
+func IOCAsync[T any](fn func() T) *Future[T] {
+	// Initialize the instances map only once
+	once.Do(initializeContainer)
+
+	fnPtr := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Entry()
+	id := keyID(asyncKey{fn: fnPtr})
+
+	// The construction outlives the caller, so it keeps the context values but not
+	// the cancellation
+	ctx := ResolutionContext()
+	if err := ctx.Err(); err != nil {
+		future := &Future[T]{fnPtr: fnPtr, done: make(chan struct{})}
+		future.err = fmt.Errorf("async %s: %w", runtime.FuncForPC(fnPtr).Name(), err)
+		close(future.done)
+		return future
+	}
+
+	instance := resolveSingleton(id, func() any {
+		return &Future[T]{fnPtr: fnPtr, ctx: context.WithoutCancel(ctx), done: make(chan struct{})}
+	})
+
+	future, ok := instance.(*Future[T])
+	if !ok {
+		panic(fmt.Sprintf("type assertion failed in IOCAsync: expected %T, got %T for function %s",
+			(*Future[T])(nil), instance, runtime.FuncForPC(fnPtr).Name()))
+	}
+
+	// Start only once the future is cached, so a factory failing right away can drop it
+	future.start.Do(func() {
+		go future.run(id, fn)
+	})
+	return future
+}
+
+// run builds the value on a background goroutine
+func (f *Future[T]) run(id uintptr, fn func() T) {
+	defer releaseResolutionPath()
+	defer close(f.done)
+	defer enterResolutionContext(f.ctx)()
+	defer func() {
+		if r := recover(); r != nil {
+			f.err = fmt.Errorf("async %s: %v", runtime.FuncForPC(f.fnPtr).Name(), r)
+
+			// Drop the failed future so a later IOCAsync call retries
+			mu.Lock()
+			if instances[id] == any(f) {
+				delete(instances, id)
+				delete(types, id)
+				delete(scopes, id)
+				delete(instanceInfos, id)
+			}
+			mu.Unlock()
+		}
+	}()
+
+	f.value = IOC(fn)
+}
+
+// Await blocks until the value is built or ctx is done. It returns the value or the
+// error raised while building it, or the context error if ctx ends first. Awaiting a
+// future whose factory depends on a singleton the calling goroutine is still building
+// returns an error instead of deadlocking.
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	default:
+	}
+
+	// Register the wait so a factory waiting for this goroutine is reported as a cycle
+	gid := getGoroutineID()
+	flightMutex.Lock()
+	if cycle := waitCycle(gid, f.fnPtr); cycle != nil {
+		flightMutex.Unlock()
+		emit(Event{Kind: EventCycleDetected, Name: keyLabel(f.fnPtr), Path: fmt.Sprintf("%v", cycle)})
+		var zero T
+		return zero, fmt.Errorf("circular dependency detected across goroutines: %v", cycle)
+	}
+	waitingOn[gid] = f.fnPtr
+	flightMutex.Unlock()
+
+	defer func() {
+		flightMutex.Lock()
+		delete(waitingOn, gid)
+		flightMutex.Unlock()
+	}()
+
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Done returns a channel that is closed once the value is built or failed
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
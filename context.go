@@ -0,0 +1,240 @@
+package gioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// resolutionContexts holds the context of the IOCCtx resolution in progress on each goroutine
+	resolutionContexts = sync.Map{} // map[goroutineID]context.Context
+	// activeContexts lets resolutions skip the lookup when no IOCCtx call is in progress
+	activeContexts atomic.Int64
+
+	// contextType is the reflect.Type of context.Context
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// WithProviderTimeout limits how long the factory fn may take to build an instance
+// when it is resolved with IOCCtx or IOCCtxErr. The factory receives a context with
+// the deadline applied, and an instance returned after the deadline is disposed if it
+// is Disposable or an io.Closer, and reported as context.DeadlineExceeded. Zero removes
+// the limit.
+//
+// Example:
+//
+//	gioc.Configure(gioc.WithProviderTimeout(NewDatabase, 5*time.Second))
+func WithProviderTimeout(fn interface{}, timeout time.Duration) Option {
+	fnPtr := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Entry()
+	return func(c *containerConfig) {
+		if c.providerTimeouts == nil {
+			c.providerTimeouts = make(map[uintptr]time.Duration)
+		}
+		if timeout <= 0 {
+			delete(c.providerTimeouts, fnPtr)
+			return
+		}
+		c.providerTimeouts[fnPtr] = timeout
+	}
+}
+
+// IOCCtx resolves a factory that takes a context, so a slow constructor can observe
+// cancellation and deadlines. It behaves like IOC: instances are cached according to
+// scope, and a cached instance is returned without calling fn.
+//
+// While fn runs, ctx is the resolution context of the goroutine. It is passed to the
+// Init method of components implementing Initializer, to context.Context parameters of
+// InjectConstructor and is returned by ResolutionContext, so nested factories see the
+// same cancellation and deadlines.
+//
+// IOCCtx panics if ctx is done before or while the instance is built. Use IOCCtxErr to
+// get the failure as an error.
+//
+// Example:
+//
+//	func NewCatalog(ctx context.Context) *Catalog {
+//	    return &Catalog{items: loadItems(ctx)}
+//	}
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	catalog := gioc.IOCCtx(ctx, NewCatalog)
+func IOCCtx[T any](ctx context.Context, fn func(context.Context) T, scope ...Scope) T {
+	instance, err := resolveWithContext(ctx, reflect.ValueOf(fn).Pointer(), reflect.TypeOf((*T)(nil)).Elem(), scope, func(buildCtx context.Context) (any, error) {
+		return fn(buildCtx), nil
+	})
+	if err != nil {
+		panic(err.Error())
+	}
+	return castContextInstance[T](instance, "IOCCtx")
+}
+
+// IOCCtxErr resolves a factory that takes a context and returns an error. It behaves
+// like IOCCtx, but failures of fn, context errors and strict mode violations for fn are
+// returned instead of causing a panic. Nothing is cached when fn fails, so a later call
+// tries again.
+//
+// Example:
+//
+//	func NewDatabase(ctx context.Context) (*Database, error) {
+//	    conn, err := sql.Open("postgres", dsn)
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    return &Database{conn: conn}, conn.PingContext(ctx)
+//	}
+//
+//	db, err := gioc.IOCCtxErr(ctx, NewDatabase)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func IOCCtxErr[T any](ctx context.Context, fn func(context.Context) (T, error), scope ...Scope) (T, error) {
+	instance, err := resolveWithContext(ctx, reflect.ValueOf(fn).Pointer(), reflect.TypeOf((*T)(nil)).Elem(), scope, func(buildCtx context.Context) (any, error) {
+		return fn(buildCtx)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return castContextInstance[T](instance, "IOCCtxErr"), nil
+}
+
+// ResolutionContext returns the context of the IOCCtx or IOCCtxErr resolution in
+// progress on the current goroutine, or context.Background when there is none.
+// Factories taking no arguments can use it to observe the caller's cancellation.
+//
+// Example:
+//
+//	func NewSearchIndex() *SearchIndex {
+//	    return buildIndex(gioc.ResolutionContext())
+//	}
+func ResolutionContext() context.Context {
+	if activeContexts.Load() == 0 {
+		return context.Background()
+	}
+	if ctx, ok := resolutionContexts.Load(getGoroutineID()); ok {
+		return ctx.(context.Context)
+	}
+	return context.Background()
+}
+
+// resolveWithContext resolves the factory at pc, declared to return result, with build,
+// making ctx the resolution context while it runs. Strict mode violations, errors
+// returned by build and context errors are returned; other panics raised while building
+// propagate.
+func resolveWithContext(ctx context.Context, pc uintptr, result reflect.Type, scope []Scope, build func(context.Context) (any, error)) (instance any, err error) {
+	// Initialize the instances map only once
+	once.Do(initializeContainer)
+
+	fnPtr := runtime.FuncForPC(pc).Entry()
+	if err := checkRegistered(fnPtr); err != nil {
+		return nil, err
+	}
+
+	var componentScope Scope = Singleton
+	if len(scope) > 0 {
+		componentScope = scope[0]
+	}
+
+	// Check for dependency cycles the same way as IOC
+	if !fastPath.Load() {
+		registerProvider(fnPtr, result, componentScope, 2)
+		panicOnCycle(fnPtr)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", keyLabel(fnPtr), err)
+	}
+
+	// Initializer hooks run after the factory returns, so they see the caller's context
+	restore := enterResolutionContext(ctx)
+	defer restore()
+
+	// buildErr is raised as a panic to leave resolve without caching anything, and
+	// recovered below
+	var buildErr error
+	currentPath := getCurrentResolutionPath()
+	defer func() {
+		if buildErr == nil {
+			return
+		}
+		r := recover()
+		if r == nil {
+			return
+		}
+		if r != any(buildErr) {
+			panic(r)
+		}
+		updateResolutionPath(currentPath)
+		instance, err = nil, buildErr
+	}()
+
+	instance = resolve(fnPtr, componentScope, func() any {
+		buildCtx, cancel := providerContext(ctx, fnPtr)
+		defer cancel()
+
+		restoreBuild := enterResolutionContext(buildCtx)
+		defer restoreBuild()
+
+		value, err := build(buildCtx)
+		if err == nil {
+			// An instance finished after the deadline is discarded, so release it
+			if err = buildCtx.Err(); err != nil {
+				if disposeErr := disposeInstance(fnPtr, value); disposeErr != nil {
+					err = errors.Join(err, fmt.Errorf("dispose %T: %w", value, disposeErr))
+				}
+			}
+		}
+		if err != nil {
+			buildErr = fmt.Errorf("construct %s: %w", keyLabel(fnPtr), err)
+			panic(buildErr)
+		}
+		return value
+	})
+	return instance, nil
+}
+
+// providerContext derives the construction context of fnPtr, applying its timeout
+func providerContext(ctx context.Context, fnPtr uintptr) (context.Context, context.CancelFunc) {
+	configMutex.RLock()
+	timeout := config.providerTimeouts[fnPtr]
+	configMutex.RUnlock()
+
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// enterResolutionContext makes ctx the resolution context of the current goroutine and
+// returns a function restoring the previous one
+func enterResolutionContext(ctx context.Context) func() {
+	gid := getGoroutineID()
+	previous, hadPrevious := resolutionContexts.Load(gid)
+	resolutionContexts.Store(gid, ctx)
+	activeContexts.Add(1)
+
+	return func() {
+		activeContexts.Add(-1)
+		if hadPrevious {
+			resolutionContexts.Store(gid, previous)
+		} else {
+			resolutionContexts.Delete(gid)
+		}
+	}
+}
+
+// castContextInstance converts a resolved instance to T
+func castContextInstance[T any](instance any, caller string) T {
+	typed, ok := instance.(T)
+	if !ok {
+		panic(fmt.Sprintf("type assertion failed in %s: expected %T, got %T", caller, *new(T), instance))
+	}
+	return typed
+}
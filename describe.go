@@ -0,0 +1,210 @@
+package gioc
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DescriptorKind tells where a described component is stored
+type DescriptorKind int
+
+const (
+	// KindSingleton is a singleton cached by IOC, IOCKey, IOCFor or IOCCtx
+	KindSingleton DescriptorKind = iota
+	// KindScoped is an instance cached in the active scope
+	KindScoped
+	// KindInstance is an instance registered with RegisterInstance
+	KindInstance
+	// KindType is an instance registered with RegisterType
+	KindType
+)
+
+// String returns the name of the descriptor kind
+func (k DescriptorKind) String() string {
+	switch k {
+	case KindSingleton:
+		return "singleton"
+	case KindScoped:
+		return "scoped"
+	case KindInstance:
+		return "instance"
+	case KindType:
+		return "type"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the kind by name
+func (k DescriptorKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a kind encoded by MarshalText
+func (k *DescriptorKind) UnmarshalText(text []byte) error {
+	for kind := KindSingleton; kind <= KindType; kind++ {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown descriptor kind %q", text)
+}
+
+// Descriptor describes a component held by the container
+type Descriptor struct {
+	// Kind tells where the component is stored
+	Kind DescriptorKind `json:"kind"`
+	// Key is the factory name, the key of keyed registrations or the registered type
+	Key string `json:"key"`
+	// Factory is the name of the function that built the component, if known
+	Factory string `json:"factory,omitempty"`
+	// Source is the file:line of the factory, if known
+	Source string `json:"source,omitempty"`
+	// Type is the type of the instance
+	Type string `json:"type"`
+	// Scope is the lifetime of the component
+	Scope string `json:"scope"`
+	// CreatedAt is when a singleton was stored, zero for other kinds
+	CreatedAt time.Time `json:"createdAt"`
+	// Resolutions counts how often the component was resolved through the container
+	Resolutions int64 `json:"resolutions"`
+	// Dependencies lists the keys of the components it resolved while being built
+	Dependencies []string `json:"dependencies,omitempty"`
+	// Instance is the component itself
+	Instance any `json:"-"`
+}
+
+// resolutionCounts counts resolutions per key
+var resolutionCounts = sync.Map{} // map[uintptr]*atomic.Int64
+
+// countResolution records a resolution of key
+func countResolution(key uintptr) {
+	counter, ok := resolutionCounts.Load(key)
+	if !ok {
+		counter, _ = resolutionCounts.LoadOrStore(key, new(atomic.Int64))
+	}
+	counter.(*atomic.Int64).Add(1)
+}
+
+// resolutionCount returns how often key was resolved
+func resolutionCount(key uintptr) int64 {
+	if counter, ok := resolutionCounts.Load(key); ok {
+		return counter.(*atomic.Int64).Load()
+	}
+	return 0
+}
+
+// Describe returns a descriptor for every singleton, registered instance, typed
+// registration and instance of the active scope, sorted by kind and key.
+//
+// Example:
+//
+//	for _, d := range gioc.Describe() {
+//	    fmt.Printf("%s %s (%s) built by %s at %s\n", d.Kind, d.Key, d.Type, d.Factory, d.Source)
+//	}
+func Describe() []Descriptor {
+	var descriptors []Descriptor
+
+	mu.RLock()
+	for key, instance := range instances {
+		descriptor := describeKey(key, instance)
+		descriptor.Kind = KindSingleton
+		descriptor.Scope = scopes[key].String()
+		descriptor.CreatedAt = instanceInfos[key].createdAt
+		for dependency := range dependencyGraph[key] {
+			descriptor.Dependencies = append(descriptor.Dependencies, keyLabel(dependency))
+		}
+		sort.Strings(descriptor.Dependencies)
+		descriptors = append(descriptors, descriptor)
+	}
+	mu.RUnlock()
+
+	if scopeCtx := getCurrentScopeContext(); scopeCtx != nil {
+		scopeCtx.mu.RLock()
+		for key, instance := range scopeCtx.instances {
+			descriptor := describeKey(key, instance)
+			descriptor.Kind = KindScoped
+			descriptor.Scope = Scoped.String()
+			descriptors = append(descriptors, descriptor)
+		}
+		scopeCtx.mu.RUnlock()
+	}
+
+	typeRegistryMutex.RLock()
+	for typeKey, instance := range typeRegistry {
+		descriptors = append(descriptors, describeRegistered(KindInstance, typeKey, instance))
+	}
+	typeRegistryMutex.RUnlock()
+
+	directMutex.RLock()
+	for typeKey, instance := range directInstances {
+		descriptors = append(descriptors, describeRegistered(KindType, typeKey, instance))
+	}
+	directMutex.RUnlock()
+
+	sort.Slice(descriptors, func(i, j int) bool {
+		if descriptors[i].Kind != descriptors[j].Kind {
+			return descriptors[i].Kind < descriptors[j].Kind
+		}
+		return descriptors[i].Key < descriptors[j].Key
+	})
+	return descriptors
+}
+
+// describeKind returns the descriptors of the given kind, sorted by key
+func describeKind(kind DescriptorKind) []Descriptor {
+	var result []Descriptor
+	for _, descriptor := range Describe() {
+		if descriptor.Kind == kind {
+			result = append(result, descriptor)
+		}
+	}
+	return result
+}
+
+// describeKey builds the descriptor fields shared by cached instances
+func describeKey(key uintptr, instance any) Descriptor {
+	descriptor := Descriptor{
+		Key:         keyLabel(key),
+		Type:        typeName(reflect.TypeOf(instance)),
+		Resolutions: resolutionCount(key),
+		Instance:    instance,
+	}
+	if fn := factoryOf(key); fn != nil {
+		file, line := fn.FileLine(fn.Entry())
+		descriptor.Factory = fn.Name()
+		descriptor.Source = fmt.Sprintf("%s:%d", file, line)
+	}
+	return descriptor
+}
+
+// describeRegistered builds the descriptor of a manually registered instance
+func describeRegistered(kind DescriptorKind, typeKey string, instance any) Descriptor {
+	return Descriptor{
+		Kind:     kind,
+		Key:      typeKey,
+		Type:     typeName(reflect.TypeOf(instance)),
+		Scope:    Singleton.String(),
+		Instance: instance,
+	}
+}
+
+// factoryOf returns the factory function of key, or nil when it is not known
+func factoryOf(key uintptr) *runtime.Func {
+	switch name := keyName(key).(type) {
+	case uintptr:
+		return runtime.FuncForPC(name)
+	case argKey:
+		return runtime.FuncForPC(name.fn)
+	case asyncKey:
+		return runtime.FuncForPC(name.fn)
+	default:
+		return nil
+	}
+}
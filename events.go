@@ -0,0 +1,255 @@
+package gioc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventKind identifies what happened in the container
+type EventKind int
+
+const (
+	// EventProviderRegistered is sent when an instance is registered with
+	// RegisterInstance or RegisterType, or a provider with Register
+	EventProviderRegistered EventKind = iota
+	// EventResolutionStarted is sent before a component is looked up or built
+	EventResolutionStarted
+	// EventResolutionFinished is sent once the component is returned or its
+	// construction failed
+	EventResolutionFinished
+	// EventInstanceDisposed is sent after a Disposable or io.Closer instance has been
+	// released by Shutdown or EvictFor
+	EventInstanceDisposed
+	// EventScopeOpened is sent by BeginScope
+	EventScopeOpened
+	// EventScopeClosed is sent when the cleanup function returned by BeginScope runs
+	EventScopeClosed
+	// EventCycleDetected is sent before the container panics on a circular dependency
+	EventCycleDetected
+	// EventSlowFactory is sent when a factory call exceeds the threshold set with
+	// WithSlowFactoryThreshold. Duration excludes the factories it resolved.
+	EventSlowFactory
+)
+
+// String returns the name of the event kind
+func (k EventKind) String() string {
+	switch k {
+	case EventProviderRegistered:
+		return "ProviderRegistered"
+	case EventResolutionStarted:
+		return "ResolutionStarted"
+	case EventResolutionFinished:
+		return "ResolutionFinished"
+	case EventInstanceDisposed:
+		return "InstanceDisposed"
+	case EventScopeOpened:
+		return "ScopeOpened"
+	case EventScopeClosed:
+		return "ScopeClosed"
+	case EventCycleDetected:
+		return "CycleDetected"
+	case EventSlowFactory:
+		return "SlowFactory"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event describes something that happened in the container. Only the fields relevant
+// to Kind are set.
+type Event struct {
+	Kind EventKind
+	// Name is the factory name, the key of keyed registrations or the registered type
+	Name string
+	// Provider is the factory name of resolution events, shared by every key of IOCFor
+	Provider string
+	// Type is the type of the instance, when known
+	Type reflect.Type
+	// Scope is the scope the component was resolved in
+	Scope Scope
+	// ScopeID identifies the scope of scope events
+	ScopeID ScopeID
+	// Duration is the time the resolution took, including construction on a cache miss
+	Duration time.Duration
+	// CacheHit reports whether a resolution returned an existing instance
+	CacheHit bool
+	// Path describes the components involved in a detected cycle
+	Path string
+	// Err is the failure of a resolution or a disposal
+	Err error
+}
+
+// Listener receives container events. OnEvent is called synchronously on the
+// goroutine that caused the event, so it should return quickly.
+type Listener interface {
+	OnEvent(event Event)
+}
+
+// ListenerFunc adapts a function to the Listener interface
+type ListenerFunc func(event Event)
+
+// OnEvent calls f(event)
+func (f ListenerFunc) OnEvent(event Event) {
+	f(event)
+}
+
+var (
+	// listeners holds the registered listeners, replaced on every change
+	listeners      []*Listener
+	listenersMutex sync.RWMutex
+	// listenerCount lets the resolution path skip building events when nobody listens
+	listenerCount atomic.Int64
+)
+
+// AddListener registers listener for container events and returns a function that
+// removes it. Resolution events are sent for IOC, IOCKey, IOCFor and IOCCtx calls.
+//
+// Example:
+//
+//	remove := gioc.AddListener(gioc.ListenerFunc(func(e gioc.Event) {
+//	    if e.Kind == gioc.EventResolutionFinished && !e.CacheHit {
+//	        log.Printf("built %s in %v", e.Name, e.Duration)
+//	    }
+//	}))
+//	defer remove()
+func AddListener(listener Listener) func() {
+	entry := &listener
+
+	listenersMutex.Lock()
+	listeners = append(append([]*Listener(nil), listeners...), entry)
+	listenerCount.Add(1)
+	listenersMutex.Unlock()
+
+	var removeOnce sync.Once
+	return func() {
+		removeOnce.Do(func() {
+			listenersMutex.Lock()
+			defer listenersMutex.Unlock()
+
+			remaining := make([]*Listener, 0, len(listeners))
+			for _, registered := range listeners {
+				if registered != entry {
+					remaining = append(remaining, registered)
+				}
+			}
+			listeners = remaining
+			listenerCount.Add(-1)
+		})
+	}
+}
+
+// hasListeners reports whether any listener or logger receives events
+func hasListeners() bool {
+	return listenerCount.Load() > 0 || containerLogger.Load() != nil
+}
+
+// emit sends event to the configured logger and every registered listener
+func emit(event Event) {
+	if !hasListeners() {
+		return
+	}
+	if logger := containerLogger.Load(); logger != nil {
+		logEvent(logger, event)
+	}
+
+	listenersMutex.RLock()
+	current := listeners
+	listenersMutex.RUnlock()
+
+	for _, listener := range current {
+		(*listener).OnEvent(event)
+	}
+}
+
+// resolve returns the instance stored under key for the given scope, calling create
+// when there is none yet, and reports the resolution to the listeners.
+func resolve(key uintptr, componentScope Scope, create func() any) any {
+	countResolution(key)
+	if listenerCount.Load() == 0 {
+		logger := containerLogger.Load()
+		if logger == nil {
+			return resolveInstance(key, componentScope, create)
+		}
+		// Only failures are logged above debug level, so skip the events otherwise
+		if !logger.Enabled(context.Background(), slog.LevelDebug) {
+			defer logResolutionFailure(logger, key, componentScope)
+			return resolveInstance(key, componentScope, create)
+		}
+	}
+
+	name, provider := keyLabel(key), providerLabel(key)
+	emit(Event{Kind: EventResolutionStarted, Name: name, Provider: provider, Scope: componentScope})
+
+	started := time.Now()
+	built := false
+	finished := false
+	defer func() {
+		if finished {
+			return
+		}
+		// r is nil when the factory called runtime.Goexit, which keeps unwinding
+		r := recover()
+		event := Event{
+			Kind:     EventResolutionFinished,
+			Name:     name,
+			Provider: provider,
+			Scope:    componentScope,
+			Duration: time.Since(started),
+		}
+		if r != nil {
+			event.Err = panicError(r)
+		}
+		emit(event)
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	instance := resolveInstance(key, componentScope, func() any {
+		built = true
+		return create()
+	})
+	finished = true
+
+	emit(Event{
+		Kind:     EventResolutionFinished,
+		Name:     name,
+		Provider: provider,
+		Type:     reflect.TypeOf(instance),
+		Scope:    componentScope,
+		Duration: time.Since(started),
+		CacheHit: !built,
+	})
+	return instance
+}
+
+// logResolutionFailure logs the panic unwinding a resolution of key, if any, and
+// keeps it unwinding. It is deferred instead of the resolution events when only
+// failures would be logged.
+func logResolutionFailure(logger *slog.Logger, key uintptr, componentScope Scope) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	logEvent(logger, Event{
+		Kind:     EventResolutionFinished,
+		Name:     keyLabel(key),
+		Provider: providerLabel(key),
+		Scope:    componentScope,
+		Err:      panicError(r),
+	})
+	panic(r)
+}
+
+// panicError converts a recovered panic value to an error
+func panicError(r any) error {
+	if err, ok := r.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", r)
+}
@@ -0,0 +1,268 @@
+package gioc
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// maxExplainChains bounds the chains collected by Explain, whose number grows
+// exponentially with the dependencies shared along the way
+const maxExplainChains = 1000
+
+// Explanation tells which roots pulled the components of a type into the process
+type Explanation struct {
+	// Type is the explained type
+	Type reflect.Type
+	// Components are the resolved providers building Type, sorted by name
+	Components []string
+	// Chains are the resolution chains recorded in the dependency graph, each one
+	// starting at a root and ending at one of Components
+	Chains [][]string
+	// Truncated is set when there were more chains than Explain collects
+	Truncated bool
+}
+
+// String renders the explanation as text, one chain per line
+func (e Explanation) String() string {
+	if len(e.Components) == 0 {
+		return fmt.Sprintf("%v was not instantiated\n", e.Type)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v is built by %s\n", e.Type, strings.Join(e.Components, ", "))
+	for _, chain := range e.Chains {
+		fmt.Fprintf(&b, "  %s\n", strings.Join(chain, " -> "))
+	}
+	if e.Truncated {
+		fmt.Fprintln(&b, "  ... more chains omitted")
+	}
+	return b.String()
+}
+
+// Register declares fn as a provider without resolving it. fn is a factory as passed
+// to IOC, IOCFor, IOCCtx or IOCCtxErr. Registered providers are reported by
+// DeadProviders until they are resolved, which helps finding wiring that is no longer
+// used, and they are the only providers that can be resolved in strict mode. Injected
+// Lazy and Provider handles build their target with the registered factory returning
+// it, in the registered scope.
+//
+// Example:
+//
+//	gioc.Register(NewUserRepository)
+//	gioc.Register(NewRequestLogger, gioc.Scoped)
+func Register(fn interface{}, scope ...Scope) {
+	fnValue := reflect.ValueOf(fn)
+	if fnValue.Kind() != reflect.Func || fnValue.IsNil() || fnValue.Type().NumOut() == 0 {
+		panic(fmt.Sprintf("Register requires a factory function, got %T", fn))
+	}
+	fnPtr := runtime.FuncForPC(fnValue.Pointer()).Entry()
+	result := fnValue.Type().Out(0)
+
+	var componentScope Scope = Singleton
+	if len(scope) > 0 {
+		componentScope = scope[0]
+	}
+
+	site := ""
+	if _, file, line, ok := runtime.Caller(1); ok {
+		site = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+
+	providersMutex.Lock()
+	info, known := providers[fnPtr]
+	if !known {
+		info = &providerInfo{result: result, scope: componentScope, site: site}
+		providers[fnPtr] = info
+	}
+	info.registered = true
+	info.factory = fnValue
+	providersMutex.Unlock()
+
+	emit(Event{Kind: EventProviderRegistered, Name: keyLabel(fnPtr), Type: result, Scope: componentScope})
+}
+
+// Explain returns every resolution chain, as recorded in the dependency graph, from a
+// root to a component of type T. A root is a component nothing else depends on.
+//
+// Shared dependencies multiply the chains, so at most 1000 are collected, following
+// parents in name order, and Truncated reports the rest. In production mode resolutions
+// made after Validate succeeded are not recorded in the dependency graph, so Explain
+// only sees the components resolved before.
+//
+// Example:
+//
+//	fmt.Print(gioc.Explain[*Database]())
+//	// *app.Database is built by app.NewDatabase
+//	//   app.NewServer -> app.NewUserHandler -> app.NewDatabase
+//	//   app.NewWorker -> app.NewDatabase
+func Explain[T any]() Explanation {
+	target := reflect.TypeOf((*T)(nil)).Elem()
+	explanation := Explanation{Type: target, Components: []string{}, Chains: [][]string{}}
+
+	components := make(map[uintptr]bool)
+	parents := make(map[uintptr][]uintptr)
+
+	mu.RLock()
+	for key, typ := range types {
+		if typ == target {
+			components[key] = true
+		}
+	}
+	for parent, children := range dependencyGraph {
+		for child := range children {
+			parents[child] = append(parents[child], parent)
+		}
+	}
+	mu.RUnlock()
+
+	providersMutex.RLock()
+	for key, info := range providers {
+		if info.resolved && info.result == target {
+			components[key] = true
+		}
+	}
+	providersMutex.RUnlock()
+
+	// Visit parents in name order so a truncated explanation is stable
+	labels := make(map[uintptr]string)
+	label := func(key uintptr) string {
+		if _, known := labels[key]; !known {
+			labels[key] = keyLabel(key)
+		}
+		return labels[key]
+	}
+	for _, keys := range parents {
+		sort.Slice(keys, func(i, j int) bool { return label(keys[i]) < label(keys[j]) })
+	}
+
+	// Walk from the component up to the roots, skipping parents already on the chain
+	var walk func(chain []uintptr)
+	walk = func(chain []uintptr) {
+		if len(explanation.Chains) == maxExplainChains {
+			explanation.Truncated = true
+			return
+		}
+		extended := false
+		for _, parent := range parents[chain[len(chain)-1]] {
+			if containsKey(chain, parent) {
+				continue
+			}
+			walk(append(append([]uintptr(nil), chain...), parent))
+			extended = true
+		}
+		if extended {
+			return
+		}
+
+		names := make([]string, len(chain))
+		for i, key := range chain {
+			names[len(chain)-1-i] = label(key)
+		}
+		explanation.Chains = append(explanation.Chains, names)
+	}
+
+	keys := make([]uintptr, 0, len(components))
+	for key := range components {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return label(keys[i]) < label(keys[j]) })
+	for _, key := range keys {
+		explanation.Components = append(explanation.Components, label(key))
+		walk([]uintptr{key})
+	}
+
+	sort.Slice(explanation.Chains, func(i, j int) bool {
+		return strings.Join(explanation.Chains[i], " -> ") < strings.Join(explanation.Chains[j], " -> ")
+	})
+	return explanation
+}
+
+// DeadProviders describes the providers declared with Register that were never
+// resolved, sorted by name.
+//
+// Example:
+//
+//	for _, provider := range gioc.DeadProviders() {
+//	    log.Printf("unused provider: %s", provider)
+//	}
+func DeadProviders() []string {
+	var dead []uintptr
+	providersMutex.RLock()
+	for key, info := range providers {
+		if info.registered && !info.resolved && resolutionCount(key) == 0 {
+			dead = append(dead, key)
+		}
+	}
+	providersMutex.RUnlock()
+
+	descriptions := make([]string, len(dead))
+	for i, key := range dead {
+		descriptions[i] = describeHop(key)
+	}
+	sort.Strings(descriptions)
+	return descriptions
+}
+
+// ProviderDescriptor describes a provider known to the container
+type ProviderDescriptor struct {
+	// Name is the factory name, followed by the key for IOCFor and IOCKey providers
+	Name string `json:"name"`
+	// Type is the declared result type of the factory
+	Type string `json:"type"`
+	// Scope is the scope the provider was registered or first resolved with
+	Scope string `json:"scope"`
+	// Site is the file:line where the provider was registered or first resolved
+	Site string `json:"site,omitempty"`
+	// Registered is set for providers declared with Register
+	Registered bool `json:"registered"`
+	// Resolved is set once the provider has been resolved
+	Resolved bool `json:"resolved"`
+	// Resolutions counts how often the provider was resolved
+	Resolutions int64 `json:"resolutions"`
+}
+
+// Providers describes every provider declared with Register or resolved so far,
+// sorted by name. Unlike Describe, it lists providers whether or not an instance of
+// them is cached.
+//
+// Example:
+//
+//	for _, p := range gioc.Providers() {
+//	    fmt.Printf("%s -> %s [%s] resolved=%v\n", p.Name, p.Type, p.Scope, p.Resolved)
+//	}
+func Providers() []ProviderDescriptor {
+	providersMutex.RLock()
+	descriptors := make([]ProviderDescriptor, 0, len(providers))
+	for key, info := range providers {
+		resolutions := resolutionCount(key)
+		descriptors = append(descriptors, ProviderDescriptor{
+			Name:        keyLabel(key),
+			Type:        typeName(info.result),
+			Scope:       info.scope.String(),
+			Site:        info.site,
+			Registered:  info.registered,
+			Resolved:    info.resolved || resolutions > 0,
+			Resolutions: resolutions,
+		})
+	}
+	providersMutex.RUnlock()
+
+	sort.Slice(descriptors, func(i, j int) bool {
+		return descriptors[i].Name < descriptors[j].Name
+	})
+	return descriptors
+}
+
+// containsKey reports whether keys contains key
+func containsKey(keys []uintptr, key uintptr) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package gioc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// argSource describes where a constructor parameter comes from in a Factory call
+type argSource struct {
+	// fromArgs is set when the parameter is supplied by the call-site arguments
+	fromArgs bool
+	// field is the Args struct field index, or -1 when Args itself is the parameter
+	field int
+}
+
+// Factory returns a typed function that builds T with constructor, combining
+// container-managed dependencies with values supplied at the call site.
+//
+// Parameters of the constructor are taken from Args when Args is a struct with a
+// field matching the parameter by name (case-insensitive) or, failing that, by a
+// type that occurs only once among the fields. When Args is not a struct it is used
+// for the first parameter it is assignable to. All other parameters are resolved
+// with the InjectConstructor rules, including WithDependency options, on every call.
+//
+// Factory panics when constructor is not a function returning a single value or when
+// an Args field does not match any parameter.
+//
+// Example:
+//
+//	func NewReportJob(db *Database, logger *Logger, reportID string) *ReportJob {
+//	    return &ReportJob{db: db, logger: logger, reportID: reportID}
+//	}
+//
+//	type ReportArgs struct {
+//	    ReportID string
+//	}
+//
+//	newReportJob := gioc.Factory[ReportArgs, *ReportJob](NewReportJob)
+//	job := newReportJob(ReportArgs{ReportID: "daily"})
+func Factory[Args any, T any](constructor interface{}, opts ...ConstructorOption) func(Args) T {
+	// Initialize the container if not already initialized
+	once.Do(initializeContainer)
+
+	constructorType := reflect.TypeOf(constructor)
+	if constructorType == nil || constructorType.Kind() != reflect.Func {
+		panic("constructor must be a function")
+	}
+	if constructorType.NumOut() != 1 {
+		panic("constructor must return exactly one value")
+	}
+
+	sources := mapFactoryArgs(constructor, constructorType, reflect.TypeOf((*Args)(nil)).Elem())
+	constructorValue := reflect.ValueOf(constructor)
+
+	return func(callArgs Args) T {
+		argsValue := reflect.ValueOf(&callArgs).Elem()
+
+		// Container-managed parameters are resolved on every call
+		resolver := newParamResolver(constructor, opts)
+		args := make([]reflect.Value, len(sources))
+		for i, source := range sources {
+			switch {
+			case !source.fromArgs:
+				args[i] = resolver.resolve(i, constructorType.In(i))
+			case source.field < 0:
+				args[i] = argsValue
+			default:
+				args[i] = argsValue.Field(source.field)
+			}
+		}
+
+		resultInterface := timeFactory(constructorValue.Pointer(), Transient, func() any {
+			return constructorValue.Call(args)[0].Interface()
+		})
+		postConstruct(constructorValue.Pointer(), resultInterface)
+
+		castedResult, ok := resultInterface.(T)
+		if !ok {
+			panic(fmt.Sprintf("type assertion failed in Factory: expected %T, got %T", *new(T), resultInterface))
+		}
+		return castedResult
+	}
+}
+
+// mapFactoryArgs decides which constructor parameters are supplied by argsType
+func mapFactoryArgs(constructor interface{}, constructorType, argsType reflect.Type) []argSource {
+	numIn := constructorType.NumIn()
+	sources := make([]argSource, numIn)
+
+	// Non-struct arguments fill the first parameter they are assignable to
+	if argsType.Kind() != reflect.Struct {
+		for i := 0; i < numIn; i++ {
+			if argsType.AssignableTo(constructorType.In(i)) {
+				sources[i] = argSource{fromArgs: true, field: -1}
+				return sources
+			}
+		}
+		panic(fmt.Sprintf("factory argument type %v does not match any parameter of %v", argsType, constructorType))
+	}
+
+	// Count field types to know which ones can be matched by type alone
+	typeCounts := make(map[reflect.Type]int, argsType.NumField())
+	for f := 0; f < argsType.NumField(); f++ {
+		typeCounts[argsType.Field(f).Type]++
+	}
+
+	used := make([]bool, argsType.NumField())
+	for i := 0; i < numIn; i++ {
+		paramType := constructorType.In(i)
+		paramName := getParamName(constructor, i)
+
+		// Match by name first
+		for f := 0; f < argsType.NumField(); f++ {
+			field := argsType.Field(f)
+			if !used[f] && field.IsExported() && strings.EqualFold(field.Name, paramName) && field.Type.AssignableTo(paramType) {
+				sources[i] = argSource{fromArgs: true, field: f}
+				used[f] = true
+				break
+			}
+		}
+		if sources[i].fromArgs {
+			continue
+		}
+
+		// Fall back to a field whose type is unique and identical to the parameter type
+		for f := 0; f < argsType.NumField(); f++ {
+			field := argsType.Field(f)
+			if !used[f] && field.IsExported() && field.Type == paramType && typeCounts[field.Type] == 1 {
+				sources[i] = argSource{fromArgs: true, field: f}
+				used[f] = true
+				break
+			}
+		}
+	}
+
+	// Every argument field has to end up in the constructor call
+	for f := 0; f < argsType.NumField(); f++ {
+		if !used[f] {
+			panic(fmt.Sprintf("field %s of factory arguments %v does not match any parameter of %v",
+				argsType.Field(f).Name, argsType, constructorType))
+		}
+	}
+
+	return sources
+}
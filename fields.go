@@ -0,0 +1,84 @@
+package gioc
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// injectTag is the struct tag marking fields populated by InjectFields
+const injectTag = "gioc"
+
+// InjectFields populates the fields of the struct pointed to by target that are
+// tagged with `gioc:"inject"`.
+//
+// Fields are resolved from the deps factories first: a factory whose result is
+// assignable to the field type is resolved as a singleton, as IOC would. Other fields
+// are looked up by type in the active scope, the singletons and the instances added
+// with RegisterInstance or RegisterType. Lazy and Provider fields are bound to resolve
+// their target type the same way when Get is called. Unexported fields are
+// supported. InjectFields panics if target is not a pointer to a struct or if a
+// non-handle field has no matching instance.
+//
+// Example:
+//
+//	type Handler struct {
+//	    DB     *Database                 `gioc:"inject"`
+//	    Mailer *gioc.Lazy[*Mailer]       `gioc:"inject"`
+//	    Jobs   *gioc.Provider[*Job]      `gioc:"inject"`
+//	}
+//
+//	func NewHandler() *Handler {
+//	    h := &Handler{}
+//	    gioc.InjectFields(h)
+//	    return h
+//	}
+func InjectFields(target interface{}, deps ...interface{}) {
+	// Initialize the container if not already initialized
+	once.Do(initializeContainer)
+
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() || targetValue.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("InjectFields requires a non-nil pointer to a struct, got %T", target))
+	}
+
+	structValue := targetValue.Elem()
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Tag.Get(injectTag) != "inject" {
+			continue
+		}
+
+		fieldValue := structValue.Field(i)
+		if !field.IsExported() {
+			// Tagged unexported fields are opted in explicitly, so write them through their address
+			fieldValue = reflect.NewAt(field.Type, unsafe.Pointer(fieldValue.UnsafeAddr())).Elem()
+		}
+
+		if isHandleType(field.Type) {
+			fieldName, fieldTarget := field.Name, targetOf(field.Type)
+			fieldValue.Set(newHandle(field.Type, func() any {
+				return resolveField(fieldName, fieldTarget, deps, buildByType)
+			}))
+			continue
+		}
+
+		fieldValue.Set(reflect.ValueOf(resolveField(field.Name, field.Type, deps, resolveByType)))
+	}
+}
+
+// resolveField resolves the value for a tagged field from deps, or else with lookup
+func resolveField(name string, fieldType reflect.Type, deps []interface{}, lookup func(reflect.Type) (any, bool)) any {
+	for _, dep := range deps {
+		depValue := reflect.ValueOf(dep)
+		if isFactory(depValue) && depValue.Type().Out(0).AssignableTo(fieldType) {
+			return resolveFactory(depValue)
+		}
+	}
+
+	if instance, found := lookup(fieldType); found {
+		return instance
+	}
+	panic(missingDependency("field "+name, fieldType, 0))
+}
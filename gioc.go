@@ -20,9 +20,15 @@ package gioc
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"reflect"
 	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unsafe"
 )
 
 // BeginScope creates and activates a new scope context.
@@ -43,14 +49,18 @@ import (
 //	}
 func BeginScope() func() {
 	scopeContextMutex.Lock()
-	defer scopeContextMutex.Unlock()
-
 	previousScope := currentScopeContext
-	currentScopeContext = NewScopeContext()
+	scopeCtx := NewScopeContext()
+	currentScopeContext = scopeCtx
+	scopeContextMutex.Unlock()
+	activeScopeCount.Add(1)
 
+	// Listeners run outside the lock so they can query the active scope
+	emit(Event{Kind: EventScopeOpened, Scope: Scoped, ScopeID: scopeCtx.id})
+
+	var closed atomic.Bool
 	return func() {
 		scopeContextMutex.Lock()
-		defer scopeContextMutex.Unlock()
 
 		// Cleanup the scope
 		if currentScopeContext != nil {
@@ -59,6 +69,13 @@ func BeginScope() func() {
 
 		// Restore previous scope
 		currentScopeContext = previousScope
+		scopeContextMutex.Unlock()
+
+		// Report the scope as closed only once if cleanup is called repeatedly
+		if closed.CompareAndSwap(false, true) {
+			activeScopeCount.Add(-1)
+			emit(Event{Kind: EventScopeClosed, Scope: Scoped, ScopeID: scopeCtx.id})
+		}
 	}
 }
 
@@ -100,24 +117,27 @@ func GetActiveScope() string {
 //	    gioc.ListScopedInstances()
 //	}
 func ListScopedInstances() {
+	ListScopedInstancesTo(os.Stdout)
+}
+
+// ListScopedInstancesTo writes the instances in the current scope to w in the format
+// of ListScopedInstances.
+func ListScopedInstancesTo(w io.Writer) {
 	scopeCtx := getCurrentScopeContext()
 	if scopeCtx == nil {
-		fmt.Println("No active scope")
+		fmt.Fprintln(w, "No active scope")
 		return
 	}
 
-	scopeCtx.mu.RLock()
-	defer scopeCtx.mu.RUnlock()
-
-	fmt.Printf("Instances in scope %s:\n", scopeCtx.id)
-	if len(scopeCtx.instances) == 0 {
-		fmt.Println("  No instances in this scope")
+	fmt.Fprintf(w, "Instances in scope %s:\n", scopeCtx.id)
+	scoped := describeKind(KindScoped)
+	if len(scoped) == 0 {
+		fmt.Fprintln(w, "  No instances in this scope")
 		return
 	}
 
-	for key, instance := range scopeCtx.instances {
-		instanceType := reflect.TypeOf(instance)
-		fmt.Printf("  Key: %v, Type: %v, Instance: %v\n", key, instanceType, instance)
+	for _, d := range scoped {
+		fmt.Fprintf(w, "  Key: %v, Type: %v, Instance: %v\n", d.Key, d.Type, d.Instance)
 	}
 }
 
@@ -153,113 +173,44 @@ func IOC[T any](fn func() T, scope ...Scope) T {
 	// Get the function pointer using runtime instead of full reflection
 	fnPtr := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Entry()
 
-	// Check for dependency cycles
-	if hasCycle := checkForCycle(fnPtr); hasCycle {
-		cyclePath := getCyclePath()
-		panic(fmt.Sprintf("circular dependency detected: %v", cyclePath))
-	}
-
 	// Determine the scope (default to Singleton if not specified)
 	var componentScope Scope = Singleton
 	if len(scope) > 0 {
 		componentScope = scope[0]
 	}
 
-	// For Transient scope, always create a new instance
-	if componentScope == Transient {
-		return fn()
-	}
-
-	// For Scoped scope, check if we're in a scope
-	if componentScope == Scoped {
-		scopeCtx := getCurrentScopeContext()
-		if scopeCtx != nil {
-			// Try to get from current scope
-			if instance, exists := scopeCtx.Get(fnPtr); exists {
-				if typed, ok := instance.(T); ok {
-					return typed
-				}
-				funcName := runtime.FuncForPC(fnPtr).Name()
-				panic(fmt.Sprintf("type assertion failed in scoped instance: expected %T, got %T for function %s", *new(T), instance, funcName))
-			}
-
-			// Create new instance for this scope
-			// Add to resolution path for cycle detection
-			currentPath := getCurrentResolutionPath()
-			newPath := append(append([]uintptr(nil), currentPath...), fnPtr)
-			updateResolutionPath(newPath)
+	requireRegistered(fnPtr)
 
-			instance := fn()
+	// In production mode cycle checks and provider bookkeeping are deferred to cache misses
+	fast := fastPath.Load()
 
-			// Remove from resolution path
-			updateResolutionPath(currentPath)
-
-			scopeCtx.Set(fnPtr, instance)
-			return instance
-		}
-		// No active scope, behave like Transient
-		return fn()
+	// Check for dependency cycles
+	if !fast {
+		registerProvider(fnPtr, reflect.TypeOf((*T)(nil)).Elem(), componentScope, 1)
+		panicOnCycle(fnPtr)
 	}
 
-	// Singleton scope handling
-
-	// Try to get existing instance with read lock first
-	mu.RLock()
-	if instance, exists := instances[fnPtr]; exists {
-		mu.RUnlock()
-		if typed, ok := instance.(T); ok {
-			return typed
-		}
-		funcName := runtime.FuncForPC(fnPtr).Name()
-		panic(fmt.Sprintf("type assertion failed in singleton instance: expected %T, got %T for function %s", *new(T), instance, funcName))
+	// Report closures that would silently share the cached instance
+	if componentScope != Transient && closureDetection.Load() {
+		checkClosure(fnPtr, closureOf(unsafe.Pointer(&fn)))
 	}
-	mu.RUnlock()
-
-	// Get the current resolution path for this goroutine
-	currentPath := getCurrentResolutionPath()
-
-	// Create a new path with the current function (deep copy to avoid modifying the original)
-	newPath := append(append([]uintptr(nil), currentPath...), fnPtr)
-	updateResolutionPath(newPath)
-
-	// Create the instance before acquiring the write lock
-	instance := fn()
-
-	// Restore the previous path
-	updateResolutionPath(currentPath)
-
-	// Double-check pattern with write lock
-	mu.Lock()
-	defer mu.Unlock()
 
-	// Check again after acquiring write lock
-	if existingInstance, exists := instances[fnPtr]; exists {
-		if typed, ok := existingInstance.(T); ok {
-			return typed
+	// Overrides replace the instance or the factory while they are active
+	if overrideCount.Load() > 0 {
+		if fake, ok := overriddenInstance[T](); ok {
+			return fake
 		}
-		funcName := runtime.FuncForPC(fnPtr).Name()
-		panic(fmt.Sprintf("type assertion failed in singleton double-check: expected %T, got %T for function %s", *new(T), existingInstance, funcName))
+		fn = overriddenFactory(fnPtr, fn)
 	}
 
-	// Store the new instance
-	instances[fnPtr] = instance
-	// Store type information only when needed
-	if _, ok := types[fnPtr]; !ok {
-		types[fnPtr] = reflect.TypeOf(instance)
+	// Singleton and Scoped instances are cached under the function pointer, Transient
+	// resolutions always create a new instance
+	instance := resolve(fnPtr, componentScope, func() any { return fn() })
+	if typed, ok := instance.(T); ok {
+		return typed
 	}
-	scopes[fnPtr] = componentScope
-
-	// Set up finalizer for cleanup
-	runtime.SetFinalizer(instance, func(interface{}) {
-		mu.Lock()
-		delete(instances, fnPtr)
-		delete(types, fnPtr)
-		delete(scopes, fnPtr)
-		delete(dependencyGraph, fnPtr)
-		mu.Unlock()
-	})
-
-	return instance
+	funcName := runtime.FuncForPC(fnPtr).Name()
+	panic(fmt.Sprintf("type assertion failed in %s instance: expected %T, got %T for function %s", componentScope, *new(T), instance, funcName))
 }
 
 // DirectIOC is a minimal reflection version of IOC
@@ -271,21 +222,44 @@ func DirectIOC[T any](fn func() T, scope ...Scope) T {
 	// Get function pointer directly
 	fnPtr := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Entry()
 
-	// Check for dependency cycles the same way as IOC
-	if hasCycle := checkForCycle(fnPtr); hasCycle {
-		cyclePath := getCyclePath()
-		panic(fmt.Sprintf("circular dependency detected: %v", cyclePath))
-	}
-
 	// Determine scope
 	var componentScope Scope = Singleton
 	if len(scope) > 0 {
 		componentScope = scope[0]
 	}
 
+	requireRegistered(fnPtr)
+
+	// In production mode cycle checks are deferred to cache misses
+	fast := fastPath.Load()
+
+	// Check for dependency cycles the same way as IOC
+	if !fast {
+		registerProvider(fnPtr, reflect.TypeOf((*T)(nil)).Elem(), componentScope, 1)
+		panicOnCycle(fnPtr)
+	}
+
+	// Overrides replace the instance or the factory while they are active
+	if overrideCount.Load() > 0 {
+		if fake, ok := overriddenInstance[T](); ok {
+			return fake
+		}
+		fn = overriddenFactory(fnPtr, fn)
+	}
+
+	// DirectIOC does not cache Scoped instances, but strict mode still needs a scope
+	if componentScope == Scoped {
+		requireActiveScope(fnPtr)
+	}
+
 	// For Transient scope, always create a new instance
 	if componentScope == Transient {
-		return fn()
+		return buildTransient(fnPtr, fn)
+	}
+
+	// Report closures that would silently share the cached instance
+	if closureDetection.Load() {
+		checkClosure(fnPtr, closureOf(unsafe.Pointer(&fn)))
 	}
 
 	// Try to get existing instance with read lock first
@@ -300,6 +274,10 @@ func DirectIOC[T any](fn func() T, scope ...Scope) T {
 	}
 	mu.RUnlock()
 
+	if fast {
+		panicOnCycle(fnPtr)
+	}
+
 	// Get the current resolution path for this goroutine
 	currentPath := getCurrentResolutionPath()
 
@@ -308,11 +286,14 @@ func DirectIOC[T any](fn func() T, scope ...Scope) T {
 	updateResolutionPath(newPath)
 
 	// Create new instance
-	instance := fn()
+	instance := timeFactory(fnPtr, componentScope, fn)
 
 	// Restore the previous path
 	updateResolutionPath(currentPath)
 
+	// A failing initializer or validator panics here, so the instance is never cached
+	postConstruct(fnPtr, instance)
+
 	// Only store if singleton
 	if componentScope == Singleton {
 		mu.Lock()
@@ -329,7 +310,7 @@ func DirectIOC[T any](fn func() T, scope ...Scope) T {
 
 		instances[fnPtr] = instance
 		// Store type information for better error messages
-		if _, ok := types[fnPtr]; !ok {
+		if _, ok := types[fnPtr]; !ok && !fast {
 			types[fnPtr] = reflect.TypeOf(instance)
 		}
 		scopes[fnPtr] = componentScope
@@ -351,20 +332,20 @@ func DirectIOC[T any](fn func() T, scope ...Scope) T {
 //	    gioc.ListInstances()
 //	}
 func ListInstances() {
-	mu.RLock()
-	defer mu.RUnlock()
+	ListInstancesTo(os.Stdout)
+}
 
-	fmt.Println("Registered instances:")
-	for key, instance := range instances {
-		scope := scopes[key]
-		scopeName := "Singleton"
-		switch scope {
-		case Transient:
-			scopeName = "Transient"
-		case Scoped:
-			scopeName = "Scoped"
-		}
-		fmt.Printf("Key: %v, Type: %v, Scope: %s, Instance: %v\n", key, types[key], scopeName, instance)
+// ListInstancesTo writes the registered instances to w in the format of ListInstances.
+// Use it to send the listing to a log file or buffer instead of stdout.
+//
+// Example:
+//
+//	var buf bytes.Buffer
+//	gioc.ListInstancesTo(&buf)
+func ListInstancesTo(w io.Writer) {
+	fmt.Fprintln(w, "Registered instances:")
+	for _, d := range describeKind(KindSingleton) {
+		fmt.Fprintf(w, "Key: %v, Type: %v, Scope: %s, Instance: %v\n", d.Key, d.Type, d.Scope, d.Instance)
 	}
 }
 
@@ -386,10 +367,15 @@ func RegisterInstance(instance interface{}) {
 	typeKey := instanceType.String() // Use the full type name as key
 
 	typeRegistryMutex.Lock()
-	defer typeRegistryMutex.Unlock()
-
+	if existing, exists := typeRegistry[typeKey]; exists && strictMode.Load() {
+		typeRegistryMutex.Unlock()
+		panic(typeCollision("RegisterInstance", typeKey, existing, instanceType))
+	}
 	// Store in the type registry
 	typeRegistry[typeKey] = instance
+	typeRegistryMutex.Unlock()
+
+	emit(Event{Kind: EventProviderRegistered, Name: typeKey, Type: instanceType})
 }
 
 // GetInstance retrieves a registered instance by type.
@@ -414,6 +400,10 @@ func GetInstance[T any]() T {
 		instanceType = reflect.TypeOf((*T)(nil)).Elem()
 	}
 
+	if fake, ok := overriddenInstance[T](); ok {
+		return fake
+	}
+
 	typeKey := instanceType.String()
 
 	typeRegistryMutex.RLock()
@@ -421,7 +411,11 @@ func GetInstance[T any]() T {
 	typeRegistryMutex.RUnlock()
 
 	if !exists {
-		panic(fmt.Sprintf("no instance registered for type %v", instanceType))
+		message := fmt.Sprintf("no instance registered for type %v", instanceType)
+		for _, suggestion := range suggestionsFor(instanceType) {
+			message += fmt.Sprintf("\n\tdid you mean %s?", suggestion)
+		}
+		panic(message)
 	}
 
 	// Convert to the correct type
@@ -453,6 +447,9 @@ func GetInstanceCount() int {
 
 // MemoryStats returns statistics about the container's memory usage
 func MemoryStats() map[string]int {
+	// Callers such as HTTP handlers run on short-lived goroutines, so do not create a path
+	currentPath := peekResolutionPath()
+
 	mu.RLock()
 	paramNameCacheMutex.RLock()
 	directMutex.RLock()
@@ -464,8 +461,8 @@ func MemoryStats() map[string]int {
 		"dependencyGraph":   len(dependencyGraph),
 		"paramNameCache":    len(paramNameCache),
 		"directInstances":   len(directInstances),
-		"currentPathCap":    cap(getCurrentResolutionPath()),
-		"currentPathLen":    len(getCurrentResolutionPath()),
+		"currentPathCap":    cap(currentPath),
+		"currentPathLen":    len(currentPath),
 		"tempPathBufferCap": cap(tempPathBuffer),
 	}
 
@@ -550,7 +547,7 @@ func TypedInjectConstructor[T any, D1 any](
 	dep1 func() D1,
 ) T {
 	// Get dependencies with minimal reflection
-	d1 := IOC(dep1)
+	d1 := resolveTyped(dep1)
 
 	// Call constructor directly without reflection
 	return constructor(d1)
@@ -563,8 +560,8 @@ func TypedInjectConstructor2[T any, D1 any, D2 any](
 	dep2 func() D2,
 ) T {
 	// Get dependencies with minimal reflection
-	d1 := IOC(dep1)
-	d2 := IOC(dep2)
+	d1 := resolveTyped(dep1)
+	d2 := resolveTyped(dep2)
 
 	// Call constructor directly without reflection
 	return constructor(d1, d2)
@@ -578,9 +575,9 @@ func TypedInjectConstructor3[T any, D1 any, D2 any, D3 any](
 	dep3 func() D3,
 ) T {
 	// Get dependencies with minimal reflection
-	d1 := IOC(dep1)
-	d2 := IOC(dep2)
-	d3 := IOC(dep3)
+	d1 := resolveTyped(dep1)
+	d2 := resolveTyped(dep2)
+	d3 := resolveTyped(dep3)
 
 	// Call constructor directly without reflection
 	return constructor(d1, d2, d3)
@@ -593,7 +590,7 @@ func CreateFactory[T any, D1 any](
 	dep1 func() D1,
 ) func() T {
 	return func() T {
-		d1 := IOC(dep1)
+		d1 := resolveTyped(dep1)
 		return constructor(d1)
 	}
 }
@@ -605,8 +602,8 @@ func CreateFactory2[T any, D1 any, D2 any](
 	dep2 func() D2,
 ) func() T {
 	return func() T {
-		d1 := IOC(dep1)
-		d2 := IOC(dep2)
+		d1 := resolveTyped(dep1)
+		d2 := resolveTyped(dep2)
 		return constructor(d1, d2)
 	}
 }
@@ -619,9 +616,9 @@ func CreateFactory3[T any, D1 any, D2 any, D3 any](
 	dep3 func() D3,
 ) func() T {
 	return func() T {
-		d1 := IOC(dep1)
-		d2 := IOC(dep2)
-		d3 := IOC(dep3)
+		d1 := resolveTyped(dep1)
+		d2 := resolveTyped(dep2)
+		d3 := resolveTyped(dep3)
 		return constructor(d1, d2, d3)
 	}
 }
@@ -655,119 +652,31 @@ func InjectConstructor[T any](constructor interface{}, opts ...ConstructorOption
 	// Initialize the container if not already initialized
 	once.Do(initializeContainer)
 
-	// Create options with preallocated map to reduce allocations
-	options := &ConstructorOptions{
-		Dependencies: make(map[string]interface{}, len(opts)),
-	}
-	for _, opt := range opts {
-		opt(options)
-	}
-
 	// Get constructor function type
 	constructorType := reflect.TypeOf(constructor)
 	if constructorType.Kind() != reflect.Func {
 		panic("constructor must be a function")
 	}
 
-	// Get constructor parameters
+	// Resolve each parameter
+	resolver := newParamResolver(constructor, opts)
 	numIn := constructorType.NumIn()
 	args := make([]reflect.Value, numIn)
-
-	// Create a map for instance type lookups to avoid unnecessary iterations
-	var instanceTypeMap map[reflect.Type]reflect.Value
-
-	// Resolve each parameter
 	for i := 0; i < numIn; i++ {
-		paramType := constructorType.In(i)
-		paramName := getParamName(constructor, i)
-
-		// Try to get dependency from options
-		if factory, exists := options.Dependencies[paramName]; exists {
-			factoryValue := reflect.ValueOf(factory)
-			if factoryValue.Kind() != reflect.Func {
-				panic(fmt.Sprintf("dependency factory for %s must be a function", paramName))
-			}
-
-			// Call factory function
-			result := factoryValue.Call(nil)
-			if len(result) != 1 {
-				panic(fmt.Sprintf("dependency factory for %s must return exactly one value", paramName))
-			}
-
-			// Check type compatibility
-			if !result[0].Type().AssignableTo(paramType) {
-				panic(fmt.Sprintf("dependency type mismatch for %s: expected %v, got %v",
-					paramName, paramType, result[0].Type()))
-			}
-
-			args[i] = result[0]
-			continue
-		}
-
-		// If no explicit dependency provided, try to find a registered instance
-		found := false
-
-		// Lazy initialize the instance type map only when needed
-		if instanceTypeMap == nil {
-			instanceTypeMap = make(map[reflect.Type]reflect.Value)
-			mu.RLock()
-			for _, instance := range instances {
-				instType := reflect.TypeOf(instance)
-				instanceTypeMap[instType] = reflect.ValueOf(instance)
-			}
-			mu.RUnlock()
-		}
-
-		// Try to find a matching instance by type (more efficient than looping through all instances)
-		if val, ok := instanceTypeMap[paramType]; ok {
-			args[i] = val
-			found = true
-		} else {
-			// If no exact match, check for assignable types
-			for t, val := range instanceTypeMap {
-				if t.AssignableTo(paramType) {
-					args[i] = val
-					found = true
-					break
-				}
-			}
-		}
-
-		if !found {
-			// For test mocking, we'll allow dependency lookup by type if it exists in the options
-			for _, factory := range options.Dependencies {
-				factoryValue := reflect.ValueOf(factory)
-				if factoryValue.Kind() != reflect.Func {
-					continue
-				}
-
-				result := factoryValue.Call(nil)
-				if len(result) != 1 {
-					continue
-				}
-
-				if result[0].Type().AssignableTo(paramType) {
-					args[i] = result[0]
-					found = true
-					break
-				}
-			}
-
-			if !found {
-				panic(fmt.Sprintf("no dependency found for parameter %s of type %v", paramName, paramType))
-			}
-		}
+		args[i] = resolver.resolve(i, constructorType.In(i))
 	}
 
 	// Call constructor with resolved arguments
 	constructorValue := reflect.ValueOf(constructor)
-	result := constructorValue.Call(args)
-
-	if len(result) != 1 {
-		panic("constructor must return exactly one value")
-	}
+	resultInterface := timeFactory(constructorValue.Pointer(), Transient, func() any {
+		result := constructorValue.Call(args)
+		if len(result) != 1 {
+			panic("constructor must return exactly one value")
+		}
+		return result[0].Interface()
+	})
+	postConstruct(constructorValue.Pointer(), resultInterface)
 
-	resultInterface := result[0].Interface()
 	castedResult, ok := resultInterface.(T)
 	if !ok {
 		panic(fmt.Sprintf("type assertion failed in InjectConstructor: expected %T, got %T", *new(T), resultInterface))
@@ -784,8 +693,14 @@ func RegisterType(instance interface{}) {
 
 	// Store the instance
 	directMutex.Lock()
+	if existing, exists := directInstances[key]; exists && strictMode.Load() {
+		directMutex.Unlock()
+		panic(typeCollision("RegisterType", key, existing, typ))
+	}
 	directInstances[key] = instance
 	directMutex.Unlock()
+
+	emit(Event{Kind: EventProviderRegistered, Name: key, Type: typ})
 }
 
 // GetType retrieves an instance by type
@@ -798,12 +713,20 @@ func GetType[T any]() T {
 	}
 	key := typ.String()
 
+	if fake, ok := overriddenInstance[T](); ok {
+		return fake
+	}
+
 	directMutex.RLock()
 	instance, exists := directInstances[key]
 	directMutex.RUnlock()
 
 	if !exists {
-		panic(fmt.Sprintf("No instance registered for type %s", key))
+		message := fmt.Sprintf("No instance registered for type %s", key)
+		for _, suggestion := range suggestionsFor(typ) {
+			message += fmt.Sprintf("\n\tdid you mean %s?", suggestion)
+		}
+		panic(message)
 	}
 
 	// Type assert
@@ -849,6 +772,13 @@ func ClearInstances() {
 	types = make(map[uintptr]reflect.Type, 16)
 	scopes = make(map[uintptr]Scope, 16)
 	dependencyGraph = make(map[uintptr]map[uintptr]bool, 16)
+	instanceInfos = make(map[uintptr]instanceInfo, 16)
+	creationOrder = nil
+	resolutionCounts.Clear()
+	resetMetrics()
+	resetFactoryTimings()
+	resetProviders()
+	resetOverrides()
 
 	// Clear parameter name cache
 	paramNameCache = make(map[uintptr][]string)
@@ -861,9 +791,39 @@ func ClearInstances() {
 	typeRegistry = make(map[string]any)
 	typeRegistryMutex.Unlock()
 
+	// Clear explicit keys and closure tracking
+	keyMutex.Lock()
+	keyIDs = make(map[any]uintptr)
+	keyNames = make(map[uintptr]any)
+	keyFactories = make(map[uintptr]uintptr)
+	scopedKeyRefs = make(map[uintptr]int)
+	keyMutex.Unlock()
+
+	closureMutex.Lock()
+	closureOwners = make(map[uintptr]unsafe.Pointer)
+	closureWarned = make(map[uintptr]bool)
+	closureMutex.Unlock()
+
+	// Clear post-construction injections
+	postInjectorsMutex.Lock()
+	postInjectors = make(map[uintptr][]postInjector)
+	postInjectorCount.Store(0)
+	postInjectorsMutex.Unlock()
+	pendingInjections = sync.Map{}
+
+	// Clear lifecycle hooks
+	lifecycleMutex.Lock()
+	lifecycleHooks = nil
+	lifecycleMutex.Unlock()
+	ignoredHooks = sync.Map{}
+
 	// Clear all resolution paths - use the thread-safe method
 	clearAllResolutionPaths()
 
+	// The graph has to be validated again before production mode applies
+	validated.Store(false)
+	fastPath.Store(false)
+
 	// Clear any active scope context
 	if currentScopeContext != nil {
 		currentScopeContext.Cleanup()
@@ -920,11 +880,14 @@ func NewScopeContext() *ScopeContext {
 //	    gioc.ListDependencyStatus()
 //	}
 func ListDependencyStatus() {
-	mu.RLock()
-	defer mu.RUnlock()
+	ListDependencyStatusTo(os.Stdout)
+}
 
-	fmt.Println("IoC Container Status:")
-	fmt.Println("=====================")
+// ListDependencyStatusTo writes the dependency resolution state to w in the format of
+// ListDependencyStatus.
+func ListDependencyStatusTo(w io.Writer) {
+	fmt.Fprintln(w, "IoC Container Status:")
+	fmt.Fprintln(w, "=====================")
 
 	// Count of active goroutines with resolution paths
 	var pathCount int
@@ -933,11 +896,15 @@ func ListDependencyStatus() {
 		return true
 	})
 
-	fmt.Printf("Active Resolution Goroutines: %d\n", pathCount)
-	fmt.Printf("Registered Types: %d\n", len(types))
+	fmt.Fprintf(w, "Active Resolution Goroutines: %d\n", pathCount)
+	singletons := describeKind(KindSingleton)
+	fmt.Fprintf(w, "Registered Types: %d\n", len(singletons))
 
-	fmt.Println("\nType Registry:")
-	for key, t := range types {
-		fmt.Printf("  Key: %v, Type: %v\n", key, t)
+	fmt.Fprintln(w, "\nType Registry:")
+	for _, d := range singletons {
+		fmt.Fprintf(w, "  Key: %v, Type: %v\n", d.Key, d.Type)
+		if len(d.Dependencies) > 0 {
+			fmt.Fprintf(w, "    Depends on: %s\n", strings.Join(d.Dependencies, ", "))
+		}
 	}
 }
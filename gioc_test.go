@@ -2,12 +2,23 @@ package gioc
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
+	"log"
+	"log/slog"
 	"os"
 	"reflect"
 	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -119,6 +130,27 @@ func TestIOCMemory(t *testing.T) {
 	}
 }
 
+// TestFinalizerKeepsRebuiltInstance tests that finalizing a cleared singleton does not
+// remove the instance built again under the same key
+func TestFinalizerKeepsRebuiltInstance(t *testing.T) {
+	ClearInstances()
+	IOC(NewTestDatabase)
+	ClearInstances()
+
+	db := IOC(NewTestDatabase)
+	for i := 0; i < 5; i++ {
+		runtime.GC()
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.RLock()
+	cached := instances[reflect.ValueOf(NewTestDatabase).Pointer()]
+	mu.RUnlock()
+	if cached != db {
+		t.Errorf("Expected the rebuilt instance to stay cached, got %v", cached)
+	}
+}
+
 // TestIOCTypeSafety tests type safety
 func TestIOCTypeSafety(t *testing.T) {
 	// Clear any existing instances
@@ -526,9 +558,9 @@ func TestGetCyclePath(t *testing.T) {
 			} else if !strings.Contains(panicMsg, "circular dependency") {
 				t.Errorf("Expected panic message to contain 'circular dependency', got '%s'", panicMsg)
 			}
-			// Since the type is not registered yet (due to cycle), we expect unknown type
-			if !strings.Contains(panicMsg, "unknown(") {
-				t.Error("Cycle path should contain unknown type")
+			// The type is not cached yet, but the declared result type of the provider is known
+			if !strings.Contains(panicMsg, "-> *gioc.SelfRef [Singleton], registered at gioc_test.go:") {
+				t.Errorf("Cycle path should describe the provider, got '%s'", panicMsg)
 			}
 		}
 	}()
@@ -893,6 +925,37 @@ func TestParameterNameCache(t *testing.T) {
 	}
 }
 
+// Parameter name test types
+type ParamHolder struct {
+	db     *TestDatabase
+	logger *TestLogger
+	size   int
+}
+
+func NewParamSingle(db *TestDatabase) *ParamHolder {
+	return &ParamHolder{db: db}
+}
+
+func NewParamMultiple(db *TestDatabase, logger *TestLogger, width, height int) *ParamHolder {
+	return &ParamHolder{db: db, logger: logger, size: width * height}
+}
+
+// TestGetParamName tests that parameter names are extracted without their types
+func TestGetParamName(t *testing.T) {
+	if name := getParamName(NewParamSingle, 0); name != "db" {
+		t.Errorf("Expected db for a single parameter, got %q", name)
+	}
+
+	for i, want := range []string{"db", "logger", "width", "height"} {
+		if name := getParamName(NewParamMultiple, i); name != want {
+			t.Errorf("Expected %s for parameter %d, got %q", want, i, name)
+		}
+	}
+	if name := getParamName(NewParamMultiple, 4); name != "param4" {
+		t.Errorf("Expected the fallback name past the last parameter, got %q", name)
+	}
+}
+
 // TestMemoryOptimizations tests the memory optimization features
 func TestMemoryOptimizations(t *testing.T) {
 	// Start fresh
@@ -946,6 +1009,27 @@ func TestMemoryOptimizations(t *testing.T) {
 	}
 }
 
+// TestMemoryStats tests that reading the stats leaves no per-goroutine state behind
+func TestMemoryStats(t *testing.T) {
+	ClearInstances()
+	IOC(NewTestDatabase)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if stats := MemoryStats(); stats["instances"] != 1 || stats["currentPathLen"] != 0 {
+				t.Errorf("Unexpected stats %v", stats)
+			}
+			if _, exists := resolutionPathMap.Load(getGoroutineID()); exists {
+				t.Error("Expected MemoryStats to create no resolution path")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 // TestWithScope tests the WithScope function
 func TestWithScope(t *testing.T) {
 	ClearInstances()
@@ -1422,3 +1506,2972 @@ func BenchmarkScopedIOC(b *testing.B) {
 		})
 	})
 }
+
+// TestProductionMode tests that production mode only applies after a successful Validate
+func TestProductionMode(t *testing.T) {
+	ClearInstances()
+	defer Configure(WithProductionMode(false))
+
+	type ProdService struct{ Name string }
+	newProdService := func() *ProdService {
+		return &ProdService{Name: "prod"}
+	}
+
+	Configure(WithProductionMode(true))
+	if fastPath.Load() {
+		t.Fatal("Production mode should not apply before Validate")
+	}
+
+	if err := Validate(newProdService); err != nil {
+		t.Fatalf("Expected validation to succeed, got %v", err)
+	}
+	if !fastPath.Load() {
+		t.Fatal("Production mode should apply after a successful Validate")
+	}
+
+	// Validate registers the roots as singletons
+	if IOC(newProdService) != IOC(newProdService) {
+		t.Error("Expected the validated root to be a singleton")
+	}
+
+	// New singletons no longer fill the diagnostic type map
+	newLateService := func() *TestStruct { return &TestStruct{Value: "late"} }
+	_ = IOC(newLateService)
+	fnPtr := runtime.FuncForPC(reflect.ValueOf(newLateService).Pointer()).Entry()
+	mu.RLock()
+	_, recorded := types[fnPtr]
+	mu.RUnlock()
+	if recorded {
+		t.Error("Expected production mode to skip type bookkeeping")
+	}
+
+	// Cycles on cache misses must still be reported
+	func() {
+		defer func() {
+			r := recover()
+			panicMsg, ok := r.(string)
+			if !ok || !strings.Contains(panicMsg, "circular dependency") {
+				t.Errorf("Expected circular dependency panic in production mode, got %v", r)
+			}
+		}()
+		_ = IOC(NewCircularServiceAFactory)
+	}()
+
+	// Clearing the container requires validating again
+	ClearInstances()
+	if fastPath.Load() {
+		t.Error("Expected ClearInstances to reset production mode")
+	}
+}
+
+// TestConfigureAtomicity tests that a panicking option leaves the configuration alone
+func TestConfigureAtomicity(t *testing.T) {
+	failing := Option(func(*containerConfig) { panic("bad option") })
+
+	func() {
+		defer func() { recover() }()
+		Configure(WithProductionMode(true), WithStrictMode(true), WithMetrics(true), WithProviderTimeout(NewTestDatabase, time.Second), failing)
+	}()
+
+	if config.production {
+		t.Error("Expected production mode to stay disabled")
+	}
+	if strictMode.Load() || config.strictMode {
+		t.Error("Expected strict mode to stay disabled")
+	}
+	if listenerCount.Load() != 0 {
+		t.Error("Expected no metrics listener to be registered")
+	}
+	if len(config.providerTimeouts) != 0 {
+		t.Errorf("Expected no provider timeouts, got %v", config.providerTimeouts)
+	}
+}
+
+// TestValidateReportsErrors tests that Validate converts resolution panics into errors
+func TestValidateReportsErrors(t *testing.T) {
+	ClearInstances()
+
+	if err := Validate(NewCircularServiceAFactory); err == nil || !strings.Contains(err.Error(), "circular dependency") {
+		t.Errorf("Expected circular dependency error, got %v", err)
+	}
+	if err := Validate(func(int) *TestStruct { return nil }); err == nil {
+		t.Error("Expected error for root with arguments")
+	}
+
+	// A failed validation must not leave stale entries on the resolution path
+	if path := getCurrentResolutionPath(); len(path) != 0 {
+		t.Errorf("Expected empty resolution path after failed validation, got %v", path)
+	}
+	if err := Validate(NewTestStruct); err != nil {
+		t.Errorf("Expected validation to succeed, got %v", err)
+	}
+}
+
+// NewCircularServiceAFactory and NewCircularServiceBFactory depend on each other through IOC
+func NewCircularServiceAFactory() *CircularServiceA {
+	return NewCircularServiceA(IOC(NewCircularServiceBFactory))
+}
+
+func NewCircularServiceBFactory() *CircularServiceB {
+	return NewCircularServiceB(IOC(NewCircularServiceAFactory))
+}
+
+// TestIOCKey tests keyed registration for closures sharing a code pointer
+func TestIOCKey(t *testing.T) {
+	ClearInstances()
+
+	type Client struct{ Name string }
+	newClient := func(name string) func() *Client {
+		return func() *Client { return &Client{Name: name} }
+	}
+
+	// Plain IOC returns the first instance for every closure of the same literal
+	if IOC(newClient("a")) != IOC(newClient("b")) {
+		t.Fatal("Expected closures of the same literal to share an instance in IOC")
+	}
+
+	clientA := IOCKey("a", newClient("a"))
+	clientB := IOCKey("b", newClient("b"))
+	if clientA == clientB {
+		t.Error("Expected different instances for different keys")
+	}
+	if clientA.Name != "a" || clientB.Name != "b" {
+		t.Errorf("Expected captured values to be used, got %q and %q", clientA.Name, clientB.Name)
+	}
+	if again := IOCKey("a", newClient("other")); again != clientA {
+		t.Error("Expected the same instance for the same key")
+	}
+
+	// Scoped keyed instances live in the active scope
+	WithScope(func() {
+		scoped1 := IOCKey("scoped", newClient("s"), Scoped)
+		scoped2 := IOCKey("scoped", newClient("s"), Scoped)
+		if scoped1 != scoped2 {
+			t.Error("Expected the same keyed instance within a scope")
+		}
+	})
+
+	// Non-comparable keys are rejected
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected panic for non-comparable key")
+			}
+		}()
+		_ = IOCKey([]string{"a"}, newClient("a"))
+	}()
+}
+
+// ClosureClient is resolved through closures and method values
+type ClosureClient struct{ name string }
+
+// Clone returns a copy of the client
+func (c *ClosureClient) Clone() *ClosureClient {
+	return &ClosureClient{name: c.name}
+}
+
+// TestClosureDetection tests the warning for closures sharing a code pointer
+func TestClosureDetection(t *testing.T) {
+	ClearInstances()
+	Configure(WithClosureDetection(true))
+	defer Configure(WithClosureDetection(false))
+
+	var buf strings.Builder
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	type Client struct{ Name string }
+	for _, name := range []string{"a", "b", "c"} {
+		_ = IOC(func() *Client { return &Client{Name: name} })
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "different closure values") {
+		t.Fatalf("Expected closure collision warning, got %q", output)
+	}
+	if strings.Count(output, "different closure values") != 1 {
+		t.Errorf("Expected a single warning per factory, got %q", output)
+	}
+
+	// Plain functions never trigger the warning
+	buf.Reset()
+	_ = IOC(NewTestStruct)
+	_ = IOC(NewTestStruct)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no warning for plain functions, got %q", buf.String())
+	}
+	// Closures recreated over the same value share the instance on purpose
+	buf.Reset()
+	shared := &ClosureClient{name: "shared"}
+	newClient := func(client *ClosureClient) func() *ClosureClient {
+		return func() *ClosureClient { return client }
+	}
+	_ = IOC(newClient(shared))
+	_ = IOC(newClient(shared))
+	_ = IOC(shared.Clone)
+	_ = IOC(shared.Clone)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no warning for closures capturing the same value, got %q", buf.String())
+	}
+}
+
+// TenantDB is used to test per-argument keyed factories
+type TenantDB struct {
+	TenantID string
+	disposed bool
+}
+
+// Dispose implements Disposable
+func (db *TenantDB) Dispose() error {
+	db.disposed = true
+	return nil
+}
+
+// NewTenantDB creates a TenantDB for a tenant
+func NewTenantDB(tenantID string) *TenantDB {
+	return &TenantDB{TenantID: tenantID}
+}
+
+// TestIOCFor tests per-argument keyed factories with listing and eviction
+func TestIOCFor(t *testing.T) {
+	ClearInstances()
+
+	dbA := IOCFor(NewTenantDB, "a")
+	dbB := IOCFor(NewTenantDB, "b")
+	if dbA == dbB {
+		t.Fatal("Expected different instances for different keys")
+	}
+	if IOCFor(NewTenantDB, "a") != dbA {
+		t.Error("Expected the same instance for the same key")
+	}
+	if dbA.TenantID != "a" || dbB.TenantID != "b" {
+		t.Errorf("Expected keys to be passed to the factory, got %q and %q", dbA.TenantID, dbB.TenantID)
+	}
+
+	keys := KeysFor(NewTenantDB)
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Errorf("Expected keys [a b], got %v", keys)
+	}
+
+	keyMutex.RLock()
+	knownKeys := len(keyNames)
+	keyMutex.RUnlock()
+	if err := EvictFor(NewTenantDB, "a"); err != nil {
+		t.Fatalf("Unexpected eviction error: %v", err)
+	}
+	if !dbA.disposed {
+		t.Error("Expected evicted instance to be disposed")
+	}
+	keyMutex.RLock()
+	if len(keyNames) != knownKeys-1 || len(keyIDs) != knownKeys-1 {
+		t.Errorf("Expected the evicted key to be forgotten, got %d keys", len(keyNames))
+	}
+	keyMutex.RUnlock()
+	if keys := KeysFor(NewTenantDB); len(keys) != 1 || keys[0] != "b" {
+		t.Errorf("Expected keys [b] after eviction, got %v", keys)
+	}
+	if IOCFor(NewTenantDB, "a") == dbA {
+		t.Error("Expected a new instance after eviction")
+	}
+
+	// Evicting an unknown key is a no-op
+	if err := EvictFor(NewTenantDB, "missing"); err != nil {
+		t.Errorf("Unexpected error evicting unknown key: %v", err)
+	}
+
+	// Scoped keyed instances are cached per scope
+	WithScope(func() {
+		scoped := IOCFor(NewTenantDB, "a", Scoped)
+		if IOCFor(NewTenantDB, "a", Scoped) != scoped {
+			t.Error("Expected the same scoped instance within a scope")
+		}
+		if keys := KeysFor(NewTenantDB, Scoped); len(keys) != 1 {
+			t.Errorf("Expected one scoped key, got %v", keys)
+		}
+		if err := EvictFor(NewTenantDB, "a", Scoped); err != nil || !scoped.disposed {
+			t.Errorf("Expected scoped instance to be evicted and disposed, err=%v", err)
+		}
+	})
+
+	// Transient and ended Scoped resolutions leave no bookkeeping behind
+	bookkeeping := func() (keys, providerCount, counts int) {
+		keyMutex.RLock()
+		keys = len(keyIDs) + len(keyNames) + len(scopedKeyRefs)
+		keyMutex.RUnlock()
+		providersMutex.RLock()
+		providerCount = len(providers)
+		providersMutex.RUnlock()
+		resolutionCounts.Range(func(_, _ any) bool {
+			counts++
+			return true
+		})
+		return keys, providerCount, counts
+	}
+	IOCFor(NewTenantDB, "warm", Transient)
+	keysBefore, providersBefore, countsBefore := bookkeeping()
+	for i := 0; i < 50; i++ {
+		IOCFor(NewTenantDB, fmt.Sprintf("transient-%d", i), Transient)
+		WithScope(func() {
+			IOCFor(NewTenantDB, fmt.Sprintf("scoped-%d", i), Scoped)
+			IOCFor(NewTenantDB, fmt.Sprintf("evicted-%d", i), Scoped)
+			EvictFor(NewTenantDB, fmt.Sprintf("evicted-%d", i), Scoped)
+		})
+	}
+	keysAfter, providersAfter, countsAfter := bookkeeping()
+	if keysAfter != keysBefore || providersAfter != providersBefore || countsAfter != countsBefore {
+		t.Errorf("Expected no leftover bookkeeping, got keys %d->%d, providers %d->%d, counts %d->%d",
+			keysBefore, keysAfter, providersBefore, providersAfter, countsBefore, countsAfter)
+	}
+
+	// A scoped instance of a key with a cached singleton keeps the shared identifier
+	WithScope(func() {
+		IOCFor(NewTenantDB, "b", Scoped)
+	})
+	if IOCFor(NewTenantDB, "b") != dbB {
+		t.Error("Expected the singleton to survive the end of a scope using its key")
+	}
+}
+
+// ReportJob combines container dependencies with a call-site report ID
+type ReportJob struct {
+	db       *TestDatabase
+	logger   *TestLogger
+	reportID string
+	attempt  int
+}
+
+// NewReportJob creates a new report job
+func NewReportJob(db *TestDatabase, logger *TestLogger, reportID string, attempt int) *ReportJob {
+	return &ReportJob{db: db, logger: logger, reportID: reportID, attempt: attempt}
+}
+
+// TestFactory tests assisted injection with Factory
+func TestFactory(t *testing.T) {
+	ClearInstances()
+
+	db := IOC(NewTestDatabase)
+	logger := IOC(NewTestLogger)
+
+	t.Run("Struct Arguments", func(t *testing.T) {
+		type ReportArgs struct {
+			ReportID string
+			Attempt  int
+		}
+
+		newReportJob := Factory[ReportArgs, *ReportJob](NewReportJob)
+		job1 := newReportJob(ReportArgs{ReportID: "daily", Attempt: 1})
+		job2 := newReportJob(ReportArgs{ReportID: "weekly", Attempt: 2})
+
+		if job1 == job2 {
+			t.Error("Expected a new job for every call")
+		}
+		if job1.db != db || job1.logger != logger {
+			t.Error("Expected container dependencies to be injected")
+		}
+		if job1.reportID != "daily" || job1.attempt != 1 || job2.reportID != "weekly" || job2.attempt != 2 {
+			t.Errorf("Expected call-site arguments to be used, got %+v and %+v", job1, job2)
+		}
+	})
+
+	t.Run("Scalar Argument", func(t *testing.T) {
+		newJob := Factory[string, *TestUserService](func(db *TestDatabase, name string) *TestUserService {
+			return &TestUserService{db: &TestDatabase{connection: name}}
+		})
+		if svc := newJob("custom"); svc.db.connection != "custom" {
+			t.Errorf("Expected scalar argument to be passed, got %q", svc.db.connection)
+		}
+	})
+
+	t.Run("Unmatched Field", func(t *testing.T) {
+		type BadArgs struct {
+			ReportID string
+			Unused   float64
+		}
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected panic for unmatched argument field")
+			}
+		}()
+		_ = Factory[BadArgs, *ReportJob](NewReportJob)
+	})
+
+	t.Run("Explicit Dependencies", func(t *testing.T) {
+		type ReportArgs struct {
+			ReportID string
+			Attempt  int
+		}
+
+		other := &TestLogger{level: "debug"}
+		newReportJob := Factory[ReportArgs, *ReportJob](NewReportJob,
+			WithDependency("logger", func() *TestLogger { return other }),
+		)
+		job := newReportJob(ReportArgs{ReportID: "x"})
+		if job.logger != other {
+			t.Error("Expected WithDependency to override the registered logger")
+		}
+		if job.db != db {
+			t.Error("Expected the registered database to be injected")
+		}
+	})
+}
+
+// LazyConsumer receives deferred handles through constructor injection
+type LazyConsumer struct {
+	db     *Lazy[*TestDatabase]
+	logger *Provider[*TestLogger]
+}
+
+// NewLazyConsumer creates a new LazyConsumer
+func NewLazyConsumer(db *Lazy[*TestDatabase], logger *Provider[*TestLogger]) *LazyConsumer {
+	return &LazyConsumer{db: db, logger: logger}
+}
+
+// TestLazyAndProvider tests Lazy and Provider handles
+func TestLazyAndProvider(t *testing.T) {
+	ClearInstances()
+
+	t.Run("NewLazy", func(t *testing.T) {
+		calls := 0
+		newCounted := func() *TestStruct {
+			calls++
+			return &TestStruct{Value: "lazy"}
+		}
+
+		lazy := NewLazy(newCounted)
+		if calls != 0 {
+			t.Fatal("Expected Lazy not to resolve before Get")
+		}
+		if lazy.Get() != lazy.Get() || calls != 1 {
+			t.Errorf("Expected a single resolution, got %d calls", calls)
+		}
+	})
+
+	t.Run("Retry After Panic", func(t *testing.T) {
+		calls := 0
+		newFlaky := func() *TestStruct {
+			calls++
+			if calls == 1 {
+				panic("not ready")
+			}
+			return &TestStruct{Value: "ready"}
+		}
+
+		lazy := NewLazy(newFlaky, Transient)
+		func() {
+			defer func() { _ = recover() }()
+			lazy.Get()
+		}()
+		if value := lazy.Get(); value == nil || value.Value != "ready" {
+			t.Errorf("Expected Get to retry after a panic, got %v", value)
+		}
+	})
+
+	t.Run("NewProvider", func(t *testing.T) {
+		transient := NewProvider(NewTestStruct, Transient)
+		if transient.Get() == transient.Get() {
+			t.Error("Expected a fresh instance per call for a transient provider")
+		}
+
+		scoped := NewProvider(NewTestStruct, Scoped)
+		var first, second *TestStruct
+		WithScope(func() {
+			first = scoped.Get()
+			if scoped.Get() != first {
+				t.Error("Expected the same instance within a scope")
+			}
+		})
+		WithScope(func() { second = scoped.Get() })
+		if first == second {
+			t.Error("Expected different instances across scopes")
+		}
+	})
+
+	t.Run("InjectConstructor", func(t *testing.T) {
+		ClearInstances()
+
+		// Dependencies are registered after construction and found on Get
+		consumer := InjectConstructor[*LazyConsumer](NewLazyConsumer)
+		db := IOC(NewTestDatabase)
+		logger := IOC(NewTestLogger)
+
+		if consumer.db.Get() != db {
+			t.Error("Expected Lazy to resolve the registered database")
+		}
+		if consumer.logger.Get() != logger {
+			t.Error("Expected Provider to resolve the registered logger")
+		}
+	})
+
+	t.Run("Registered Factories", func(t *testing.T) {
+		ClearInstances()
+		defer ClearInstances()
+
+		Register(NewTestDatabase)
+		Register(NewTestLogger, Transient)
+		consumer := InjectConstructor[*LazyConsumer](NewLazyConsumer)
+
+		if consumer.logger.Get() == consumer.logger.Get() {
+			t.Error("Expected Provider to build a fresh transient on every Get")
+		}
+		if consumer.db.Get() != IOC(NewTestDatabase) {
+			t.Error("Expected Lazy to build the registered singleton")
+		}
+	})
+
+	t.Run("Named Factories", func(t *testing.T) {
+		ClearInstances()
+
+		consumer := InjectConstructor[*LazyConsumer](NewLazyConsumer,
+			WithDependency("db", NewTestDatabase),
+			WithDependency("logger", NewTestLogger),
+		)
+		if consumer.logger.Get() == consumer.logger.Get() {
+			t.Error("Expected Provider to call the factory on every Get")
+		}
+		if consumer.db.Get() != consumer.db.Get() {
+			t.Error("Expected Lazy to call the factory once")
+		}
+	})
+
+	t.Run("TypedInjectConstructor", func(t *testing.T) {
+		ClearInstances()
+
+		consumer := TypedInjectConstructor2(NewLazyConsumer,
+			LazyOf(NewTestDatabase),
+			ProviderOf(NewTestLogger),
+		)
+		if consumer.db.Get() != IOC(NewTestDatabase) {
+			t.Error("Expected Lazy to resolve through IOC")
+		}
+		if consumer.logger.Get() != IOC(NewTestLogger) {
+			t.Error("Expected Provider to resolve through IOC")
+		}
+
+		// Handles of different types must not be cached under one code pointer
+		lazyDB := TypedInjectConstructor(func(l *Lazy[*TestDatabase]) *Lazy[*TestDatabase] { return l }, LazyOf(NewTestDatabase))
+		lazyLogger := TypedInjectConstructor(func(l *Lazy[*TestLogger]) *Lazy[*TestLogger] { return l }, LazyOf(NewTestLogger))
+		if lazyDB.Get() == nil || lazyLogger.Get() == nil {
+			t.Error("Expected both handles to resolve")
+		}
+	})
+
+	t.Run("Deferred Cycle Detection", func(t *testing.T) {
+		ClearInstances()
+
+		var newSelf func() *TestStruct
+		newSelf = func() *TestStruct {
+			// Resolving the handle during construction is a real cycle
+			NewLazy(newSelf).Get()
+			return &TestStruct{}
+		}
+
+		defer func() {
+			r := recover()
+			if msg, ok := r.(string); !ok || !strings.Contains(msg, "circular dependency") {
+				t.Errorf("Expected circular dependency panic, got %v", r)
+			}
+		}()
+		_ = IOC(newSelf)
+	})
+}
+
+// TestInjectFields tests tagged field injection
+func TestInjectFields(t *testing.T) {
+	ClearInstances()
+
+	type Handler struct {
+		DB       *TestDatabase          `gioc:"inject"`
+		logger   *TestLogger            `gioc:"inject"`
+		Lazy     *Lazy[*TestStruct]     `gioc:"inject"`
+		Provider *Provider[*TestStruct] `gioc:"inject"`
+		Ignored  *TestDatabase
+	}
+
+	db := IOC(NewTestDatabase)
+	logger := IOC(NewTestLogger)
+
+	handler := &Handler{}
+	InjectFields(handler)
+
+	if handler.DB != db || handler.logger != logger {
+		t.Error("Expected tagged fields to be injected")
+	}
+	if handler.Ignored != nil {
+		t.Error("Expected untagged fields to be left alone")
+	}
+
+	// Handles resolve on Get, after the instance was registered
+	instance := IOC(NewTestStruct)
+	if handler.Lazy.Get() != instance || handler.Provider.Get() != instance {
+		t.Error("Expected handles to resolve the registered instance")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic for non-struct target")
+		}
+	}()
+	InjectFields(handler.DB.connection)
+}
+
+// EventBus and Subscriber depend on each other; the bus learns about subscribers after construction
+type EventBus struct {
+	subscribers []*Subscriber
+	Audit       *Subscriber `gioc:"inject"`
+}
+
+// Subscribe adds a subscriber to the bus
+func (b *EventBus) Subscribe(s *Subscriber) {
+	b.subscribers = append(b.subscribers, s)
+}
+
+// Subscriber receives events from the bus
+type Subscriber struct {
+	bus *EventBus
+}
+
+// NewEventBus creates a new EventBus
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// NewSubscriber creates a new Subscriber attached to the bus
+func NewSubscriber() *Subscriber {
+	return &Subscriber{bus: IOC(NewEventBus)}
+}
+
+// NewFailingBusOwner builds the bus and then fails
+func NewFailingBusOwner() *Subscriber {
+	IOC(NewEventBus)
+	panic("bus owner unavailable")
+}
+
+// NewTenantBus creates a new EventBus for a tenant
+func NewTenantBus(tenant string) *EventBus {
+	return &EventBus{}
+}
+
+// TestPostInject tests breaking cycles with post-construction setter injection
+func TestPostInject(t *testing.T) {
+	for _, root := range []string{"bus", "subscriber"} {
+		t.Run("Resolve "+root+" first", func(t *testing.T) {
+			ClearInstances()
+			PostInject(NewEventBus, func(bus *EventBus) {
+				bus.Subscribe(IOC(NewSubscriber))
+			})
+
+			if root == "subscriber" {
+				_ = IOC(NewSubscriber)
+			}
+			bus := IOC(NewEventBus)
+			subscriber := IOC(NewSubscriber)
+
+			if subscriber.bus != bus {
+				t.Error("Expected subscriber to receive the bus")
+			}
+			if len(bus.subscribers) != 1 || bus.subscribers[0] != subscriber {
+				t.Errorf("Expected bus to receive the subscriber once, got %v", bus.subscribers)
+			}
+		})
+	}
+
+	t.Run("Tagged Fields", func(t *testing.T) {
+		ClearInstances()
+		PostInjectFields(NewEventBus, NewSubscriber)
+
+		bus := IOC(NewEventBus)
+		if bus.Audit == nil || bus.Audit != IOC(NewSubscriber) || bus.Audit.bus != bus {
+			t.Error("Expected tagged field to be injected after construction")
+		}
+	})
+
+	t.Run("Constructor Cycles", func(t *testing.T) {
+		ClearInstances()
+		PostInject(NewCircularServiceAFactory, func(*CircularServiceA) {})
+
+		defer func() {
+			r := recover()
+			if msg, ok := r.(string); !ok || !strings.Contains(msg, "circular dependency") {
+				t.Errorf("Expected circular dependency panic, got %v", r)
+			}
+		}()
+		_ = IOC(NewCircularServiceAFactory)
+	})
+	t.Run("Keyed Instances", func(t *testing.T) {
+		ClearInstances()
+		PostInject(NewEventBus, func(bus *EventBus) {
+			bus.Subscribe(&Subscriber{bus: bus})
+		})
+
+		bus := IOCKey("audit", NewEventBus)
+		if len(bus.subscribers) != 1 || bus.subscribers[0].bus != bus {
+			t.Errorf("Expected IOCKey instance to be injected once, got %v", bus.subscribers)
+		}
+	})
+
+	t.Run("Argument Instances", func(t *testing.T) {
+		ClearInstances()
+		tenants := map[*EventBus]string{}
+		PostInjectFor(NewTenantBus, func(tenant string, bus *EventBus) {
+			tenants[bus] = tenant
+		})
+
+		busA := IOCFor(NewTenantBus, "a")
+		busB := IOCFor(NewTenantBus, "b")
+		IOCFor(NewTenantBus, "a")
+		if len(tenants) != 2 || tenants[busA] != "a" || tenants[busB] != "b" {
+			t.Errorf("Expected each IOCFor instance to be injected with its key, got %v", tenants)
+		}
+	})
+
+	t.Run("Failed Resolution", func(t *testing.T) {
+		ClearInstances()
+		injected := 0
+		PostInject(NewEventBus, func(*EventBus) { injected++ })
+
+		func() {
+			defer func() { recover() }()
+			IOC(NewFailingBusOwner)
+		}()
+
+		// Injections queued by the failed resolution must not run in the next one
+		IOC(NewSubscriber)
+		if injected != 0 {
+			t.Errorf("Expected the queued injection to be discarded, ran %d times", injected)
+		}
+		if path := getCurrentResolutionPath(); len(path) != 0 {
+			t.Errorf("Expected the resolution path to be reset, got %v", path)
+		}
+	})
+}
+
+// lifecycleLog records lifecycle events in the order they happen
+var lifecycleLog []string
+
+// LifecycleRepo is started before the services depending on it
+type LifecycleRepo struct{}
+
+func (r *LifecycleRepo) Start(ctx context.Context) error {
+	lifecycleLog = append(lifecycleLog, "start repo")
+	return nil
+}
+
+func (r *LifecycleRepo) Stop(ctx context.Context) error {
+	lifecycleLog = append(lifecycleLog, "stop repo")
+	return nil
+}
+
+// LifecycleService depends on LifecycleRepo
+type LifecycleService struct {
+	repo *LifecycleRepo
+	fail bool
+}
+
+func (s *LifecycleService) Start(ctx context.Context) error {
+	if s.fail {
+		return errors.New("boom")
+	}
+	lifecycleLog = append(lifecycleLog, "start service")
+	return nil
+}
+
+func (s *LifecycleService) Stop(ctx context.Context) error {
+	lifecycleLog = append(lifecycleLog, "stop service")
+	return nil
+}
+
+func NewLifecycleRepo() *LifecycleRepo {
+	return &LifecycleRepo{}
+}
+
+func NewLifecycleService() *LifecycleService {
+	return &LifecycleService{repo: IOC(NewLifecycleRepo)}
+}
+
+// TestLifecycle tests Start and Stop ordering, rollback and timeouts
+func TestLifecycle(t *testing.T) {
+	t.Run("Ordering", func(t *testing.T) {
+		ClearInstances()
+		lifecycleLog = nil
+
+		_ = IOC(NewLifecycleService)
+		OnStart(func(ctx context.Context) error {
+			lifecycleLog = append(lifecycleLog, "start hook")
+			return nil
+		})
+		OnStop(func(ctx context.Context) error {
+			lifecycleLog = append(lifecycleLog, "stop hook")
+			return nil
+		})
+
+		if err := Start(context.Background()); err != nil {
+			t.Fatalf("Unexpected start error: %v", err)
+		}
+		// Already started hooks are not run again
+		if err := Start(context.Background()); err != nil {
+			t.Fatalf("Unexpected start error: %v", err)
+		}
+		if err := Stop(context.Background()); err != nil {
+			t.Fatalf("Unexpected stop error: %v", err)
+		}
+
+		expected := "start repo,start service,start hook,stop hook,stop service,stop repo"
+		if got := strings.Join(lifecycleLog, ","); got != expected {
+			t.Errorf("Expected %q, got %q", expected, got)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		ClearInstances()
+		lifecycleLog = nil
+
+		_ = IOC(func() *LifecycleService {
+			return &LifecycleService{repo: IOC(NewLifecycleRepo), fail: true}
+		})
+
+		err := Start(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "boom") {
+			t.Fatalf("Expected start error, got %v", err)
+		}
+		if got := strings.Join(lifecycleLog, ","); got != "start repo,stop repo" {
+			t.Errorf("Expected started components to be rolled back, got %q", got)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		ClearInstances()
+		Configure(WithHookTimeout(10 * time.Millisecond))
+		defer Configure(WithHookTimeout(0))
+
+		OnStart(func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		})
+
+		if err := Start(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+	})
+
+	t.Run("Deadline Ignored By Hooks", func(t *testing.T) {
+		ClearInstances()
+		release := make(chan struct{})
+		defer close(release)
+
+		OnStart(func(ctx context.Context) error { return nil })
+		OnStop(func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+		IOC(func() *StuckResource { return &StuckResource{release: release} })
+		if err := Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		stopCtx, cancelStop := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancelStop()
+		if err := Stop(stopCtx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the stuck stop hook to time out, got %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		started := time.Now()
+		err := Shutdown(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "dispose *gioc.StuckResource") {
+			t.Errorf("Expected the stuck Close to time out, got %v", err)
+		}
+		if elapsed := time.Since(started); elapsed > time.Second {
+			t.Errorf("Expected Shutdown to return at the deadline, took %v", elapsed)
+		}
+	})
+
+	t.Run("Panicking Hooks", func(t *testing.T) {
+		ClearInstances()
+		OnStart(func(ctx context.Context) error { panic("start exploded") })
+
+		// Hooks run on their own goroutine when the context can be done
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		for _, ctx := range []context.Context{context.Background(), ctx} {
+			if err := Start(ctx); err == nil || !strings.Contains(err.Error(), "start exploded") {
+				t.Errorf("Expected the panic as an error, got %v", err)
+			}
+		}
+	})
+
+	t.Run("Transient Factories", func(t *testing.T) {
+		ClearInstances()
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(os.Stderr)
+
+		for i := 0; i < 3; i++ {
+			IOC(NewHookedWorker, Transient)
+		}
+		IOC(NewHookedWorker)
+
+		lifecycleMutex.Lock()
+		hooks := len(lifecycleHooks)
+		lifecycleMutex.Unlock()
+		if hooks != 1 {
+			t.Errorf("Expected only the singleton to register a hook, got %d", hooks)
+		}
+		if strings.Count(buf.String(), "registered by a Transient factory") != 1 {
+			t.Errorf("Expected a single warning, got %q", buf.String())
+		}
+	})
+
+	t.Run("Other Factories", func(t *testing.T) {
+		ClearInstances()
+		log.SetOutput(&bytes.Buffer{})
+		defer log.SetOutput(os.Stderr)
+
+		WithScope(func() {
+			IOC(NewHookedWorker, Scoped)
+		})
+		InjectConstructor[*HookedWorker](NewHookedWorker)
+		Factory[struct{}, *HookedWorker](NewHookedWorker)(struct{}{})
+		IOC(func() *HookedWorker {
+			return IOC(NewHookedWorker, Transient)
+		})
+
+		lifecycleMutex.Lock()
+		hooks := len(lifecycleHooks)
+		lifecycleMutex.Unlock()
+		if hooks != 0 {
+			t.Errorf("Expected hooks of non-singleton factories to be ignored, got %d", hooks)
+		}
+	})
+
+	t.Run("Restart After Shutdown", func(t *testing.T) {
+		ClearInstances()
+		first := IOC(NewCountedStarter)
+		IOC(NewHookedWorker)
+		if err := Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err := Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		second := IOC(NewCountedStarter)
+		IOC(NewHookedWorker)
+		if err := Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if first == second || first.starts != 1 || second.starts != 1 {
+			t.Errorf("Expected each instance to start once, got %d and %d", first.starts, second.starts)
+		}
+
+		lifecycleMutex.Lock()
+		hooks := len(lifecycleHooks)
+		lifecycleMutex.Unlock()
+		if hooks != 2 {
+			t.Errorf("Expected only the hooks of the new singletons, got %d", hooks)
+		}
+	})
+
+	t.Run("Evicted And Restored Singletons", func(t *testing.T) {
+		ClearInstances()
+		snap := Snapshot()
+		stopped := 0
+		newTenantWorker := func(tenant string) *HookedWorker {
+			OnStop(func(context.Context) error {
+				stopped++
+				return nil
+			})
+			return &HookedWorker{}
+		}
+
+		IOCFor(newTenantWorker, "a")
+		IOCFor(newTenantWorker, "b")
+		if err := Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err := EvictFor(newTenantWorker, "a"); err != nil {
+			t.Fatal(err)
+		}
+		lifecycleMutex.Lock()
+		hooks := len(lifecycleHooks)
+		lifecycleMutex.Unlock()
+		if stopped != 1 || hooks != 1 {
+			t.Errorf("Expected the evicted hook to be stopped and dropped, got %d stops and %d hooks", stopped, hooks)
+		}
+
+		if err := Restore(snap); err != nil {
+			t.Fatal(err)
+		}
+		lifecycleMutex.Lock()
+		hooks = len(lifecycleHooks)
+		lifecycleMutex.Unlock()
+		if hooks != 0 {
+			t.Errorf("Expected Restore to drop the hooks of singletons created since the snapshot, got %d", hooks)
+		}
+	})
+}
+
+// HookedWorker registers a start hook from its factory
+type HookedWorker struct{}
+
+func (w *HookedWorker) run(ctx context.Context) error {
+	return nil
+}
+
+func NewHookedWorker() *HookedWorker {
+	w := &HookedWorker{}
+	OnStart(w.run)
+	return w
+}
+
+// CountedStarter records how many times it was started
+type CountedStarter struct {
+	starts int
+}
+
+// Start implements Starter
+func (s *CountedStarter) Start(context.Context) error {
+	s.starts++
+	return nil
+}
+
+// NewCountedStarter creates a new CountedStarter
+func NewCountedStarter() *CountedStarter {
+	return &CountedStarter{}
+}
+
+// StuckResource ignores the shutdown context in Close
+type StuckResource struct {
+	release chan struct{}
+}
+
+func (r *StuckResource) Close() error {
+	<-r.release
+	return nil
+}
+
+// ClosableResource is disposed when the container shuts down
+type ClosableResource struct {
+	name string
+}
+
+// Close implements io.Closer
+func (r *ClosableResource) Close() error {
+	lifecycleLog = append(lifecycleLog, "close "+r.name)
+	return nil
+}
+
+// TestRun tests the application runner
+func TestRun(t *testing.T) {
+	t.Run("Graceful Shutdown", func(t *testing.T) {
+		ClearInstances()
+		lifecycleLog = nil
+
+		newResource := func() *ClosableResource { return &ClosableResource{name: "resource"} }
+		newApp := func() *LifecycleService {
+			_ = IOC(newResource)
+			return NewLifecycleService()
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		OnStart(func(context.Context) error {
+			// Request shutdown once everything has started
+			time.AfterFunc(20*time.Millisecond, cancel)
+			return nil
+		})
+
+		if err := Run(ctx, newApp, WithReturnError()); err != nil {
+			t.Fatalf("Unexpected run error: %v", err)
+		}
+
+		expected := "start repo,start service,stop service,stop repo,close resource"
+		if got := strings.Join(lifecycleLog, ","); got != expected {
+			t.Errorf("Expected %q, got %q", expected, got)
+		}
+		if count := GetInstanceCount(); count != 0 {
+			t.Errorf("Expected singletons to be removed after shutdown, got %d", count)
+		}
+	})
+
+	t.Run("Resolve Error", func(t *testing.T) {
+		ClearInstances()
+
+		err := Run(context.Background(), NewCircularServiceAFactory, WithReturnError())
+		if err == nil || !strings.Contains(err.Error(), "circular dependency") {
+			t.Errorf("Expected resolution error, got %v", err)
+		}
+	})
+
+	t.Run("Exit On Error By Default", func(t *testing.T) {
+		ClearInstances()
+
+		code := 0
+		exit = func(c int) { code = c }
+		defer func() { exit = os.Exit }()
+
+		var buf strings.Builder
+		log.SetOutput(&buf)
+		defer log.SetOutput(os.Stderr)
+
+		_ = Run(context.Background(), NewCircularServiceAFactory)
+		if code != 1 {
+			t.Errorf("Expected exit code 1 by default, got %d", code)
+		}
+	})
+
+	t.Run("Signal During Startup", func(t *testing.T) {
+		ClearInstances()
+		lifecycleLog = nil
+
+		newApp := func() *LifecycleService {
+			// The signal arrives while the graph is still being built
+			process, _ := os.FindProcess(os.Getpid())
+			if err := process.Signal(os.Interrupt); err != nil {
+				t.Skipf("Cannot signal the test process: %v", err)
+			}
+			select {
+			case <-ResolutionContext().Done():
+			case <-time.After(time.Second):
+				t.Error("Expected the signal to cancel the resolution context")
+			}
+			return NewLifecycleService()
+		}
+
+		if err := Run(context.Background(), newApp, WithSignals(os.Interrupt), WithReturnError()); err != nil {
+			t.Fatalf("Unexpected run error: %v", err)
+		}
+		if got := strings.Join(lifecycleLog, ","); got != "" {
+			t.Errorf("Expected no start hooks to run after the signal, got %q", got)
+		}
+		if count := GetInstanceCount(); count != 0 {
+			t.Errorf("Expected the built singletons to be shut down, got %d", count)
+		}
+	})
+}
+
+// InitializedService counts Init calls and can be made to fail
+type InitializedService struct {
+	initCalls int
+	initErr   error
+	valid     bool
+}
+
+// Init implements Initializer
+func (s *InitializedService) Init(ctx context.Context) error {
+	s.initCalls++
+	return s.initErr
+}
+
+// Validate implements Validator
+func (s *InitializedService) Validate() error {
+	if !s.valid {
+		return errors.New("missing configuration")
+	}
+	return nil
+}
+
+// TestPostConstructHooks tests Initializer and Validator hooks
+func TestPostConstructHooks(t *testing.T) {
+	ClearInstances()
+
+	var initErr error
+	valid := true
+	newService := func() *InitializedService {
+		return &InitializedService{initErr: initErr, valid: valid}
+	}
+	newParent := func() *TestUserService {
+		IOC(newService)
+		return &TestUserService{}
+	}
+
+	expectPanic := func(t *testing.T, substrings ...string) {
+		t.Helper()
+		r := recover()
+		msg, ok := r.(string)
+		if !ok {
+			t.Fatalf("Expected string panic, got %v", r)
+		}
+		for _, sub := range substrings {
+			if !strings.Contains(msg, sub) {
+				t.Errorf("Expected panic to contain %q, got %q", sub, msg)
+			}
+		}
+	}
+
+	t.Run("Init Failure", func(t *testing.T) {
+		initErr = errors.New("connection refused")
+		defer func() { initErr = nil }()
+		defer expectPanic(t, "initialization failed", "connection refused", " -> ")
+		_ = IOC(newParent)
+	})
+
+	t.Run("Validate Failure", func(t *testing.T) {
+		valid = false
+		defer func() { valid = true }()
+		defer expectPanic(t, "validation failed", "missing configuration")
+		_ = IOC(newService)
+	})
+
+	t.Run("Broken Instances Are Not Cached", func(t *testing.T) {
+		service := IOC(newService)
+		if service.initCalls != 1 {
+			t.Errorf("Expected Init to be called once, got %d", service.initCalls)
+		}
+		if IOC(newService) != service {
+			t.Error("Expected the healthy instance to be cached")
+		}
+		if count := GetInstanceCount(); count != 1 {
+			t.Errorf("Expected only the healthy instance to be cached, got %d", count)
+		}
+	})
+
+	t.Run("InjectConstructor", func(t *testing.T) {
+		defer expectPanic(t, "validation failed")
+		_ = InjectConstructor[*InitializedService](func() *InitializedService {
+			return &InitializedService{}
+		})
+	})
+}
+
+// WarmupShared is a slow dependency shared by several roots
+type WarmupShared struct{}
+
+// WarmupRootA and WarmupRootB both depend on WarmupShared
+type WarmupRootA struct{ shared *WarmupShared }
+type WarmupRootB struct{ shared *WarmupShared }
+
+var warmupSharedBuilds atomic.Int32
+
+func NewWarmupShared() *WarmupShared {
+	warmupSharedBuilds.Add(1)
+	time.Sleep(50 * time.Millisecond)
+	return &WarmupShared{}
+}
+
+func NewWarmupRootA() *WarmupRootA {
+	time.Sleep(50 * time.Millisecond)
+	return &WarmupRootA{shared: IOC(NewWarmupShared)}
+}
+
+func NewWarmupRootB() *WarmupRootB {
+	time.Sleep(50 * time.Millisecond)
+	return &WarmupRootB{shared: IOC(NewWarmupShared)}
+}
+
+// WarmupApp is a single root depending on both warmup roots
+type WarmupApp struct {
+	a *WarmupRootA
+	b *WarmupRootB
+}
+
+func NewWarmupApp() *WarmupApp {
+	return &WarmupApp{a: IOC(NewWarmupRootA), b: IOC(NewWarmupRootB)}
+}
+
+// WarmupCycleX and WarmupCycleY depend on each other
+type WarmupCycleX struct{}
+type WarmupCycleY struct{}
+
+func NewWarmupCycleX() *WarmupCycleX {
+	time.Sleep(20 * time.Millisecond)
+	IOC(NewWarmupCycleY)
+	return &WarmupCycleX{}
+}
+
+func NewWarmupCycleY() *WarmupCycleY {
+	time.Sleep(20 * time.Millisecond)
+	IOC(NewWarmupCycleX)
+	return &WarmupCycleY{}
+}
+
+type warmupKey struct{}
+
+// WarmupContextual records the resolution context it was built with
+type WarmupContextual struct{ ctx context.Context }
+
+func NewWarmupContextual() *WarmupContextual {
+	return &WarmupContextual{ctx: ResolutionContext()}
+}
+
+// TestWarmup tests concurrent eager initialization
+func TestWarmup(t *testing.T) {
+	t.Run("Concurrent Roots", func(t *testing.T) {
+		ClearInstances()
+		warmupSharedBuilds.Store(0)
+		Configure(WithWarmupWorkers(3))
+		defer Configure(WithWarmupWorkers(0))
+
+		started := time.Now()
+		timings, err := Warmup(context.Background(), NewWarmupRootA, NewWarmupRootB, NewWarmupShared)
+		elapsed := time.Since(started)
+		if err != nil {
+			t.Fatalf("Unexpected warmup error: %v", err)
+		}
+
+		if builds := warmupSharedBuilds.Load(); builds != 1 {
+			t.Errorf("Expected the shared dependency to be built once, got %d", builds)
+		}
+		if elapsed >= 140*time.Millisecond {
+			t.Errorf("Expected roots to be built concurrently, took %v", elapsed)
+		}
+		if len(timings) != 3 {
+			t.Fatalf("Expected 3 timings, got %d", len(timings))
+		}
+		if !strings.Contains(timings[0].Name, "NewWarmupShared") {
+			t.Errorf("Expected the shared dependency to be created first, got %s", timings[0].Name)
+		}
+		for _, timing := range timings {
+			if timing.Duration < 50*time.Millisecond {
+				t.Errorf("Expected %s to take at least 50ms, got %v", timing.Name, timing.Duration)
+			}
+		}
+		if IOC(NewWarmupRootA).shared != IOC(NewWarmupRootB).shared {
+			t.Error("Expected roots to share the dependency")
+		}
+	})
+
+	t.Run("Single Root", func(t *testing.T) {
+		ClearInstances()
+		warmupSharedBuilds.Store(0)
+		Configure(WithWarmupWorkers(3))
+		defer Configure(WithWarmupWorkers(0))
+		Register(NewWarmupRootA)
+		Register(NewWarmupRootB)
+		Register(NewWarmupShared)
+
+		// The first warmup discovers the graph one factory after the other
+		if _, err := Warmup(context.Background(), NewWarmupApp); err != nil {
+			t.Fatalf("Unexpected warmup error: %v", err)
+		}
+		if err := Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		started := time.Now()
+		timings, err := Warmup(context.Background(), NewWarmupApp)
+		elapsed := time.Since(started)
+		if err != nil {
+			t.Fatalf("Unexpected warmup error: %v", err)
+		}
+		if elapsed >= 140*time.Millisecond {
+			t.Errorf("Expected the recorded dependencies to be built concurrently, took %v", elapsed)
+		}
+		if builds := warmupSharedBuilds.Load(); builds != 2 {
+			t.Errorf("Expected the shared dependency to be built once per warmup, got %d", builds)
+		}
+		if len(timings) != 4 || !strings.Contains(timings[0].Name, "NewWarmupShared") ||
+			!strings.Contains(timings[3].Name, "NewWarmupApp") {
+			t.Errorf("Expected dependencies to be created before their dependents, got %v", timings)
+		}
+	})
+
+	t.Run("Cycles Across Goroutines", func(t *testing.T) {
+		ClearInstances()
+
+		_, err := Warmup(context.Background(), NewWarmupCycleX, NewWarmupCycleY)
+		if err == nil || !strings.Contains(err.Error(), "circular dependency") {
+			t.Errorf("Expected circular dependency error, got %v", err)
+		}
+	})
+
+	t.Run("Invalid Root", func(t *testing.T) {
+		if _, err := Warmup(context.Background(), "not a factory"); err == nil {
+			t.Error("Expected error for invalid root")
+		}
+	})
+	t.Run("Resolution Context", func(t *testing.T) {
+		ClearInstances()
+
+		ctx := context.WithValue(context.Background(), warmupKey{}, "warmup")
+		if _, err := Warmup(ctx, NewWarmupContextual); err != nil {
+			t.Fatalf("Warmup failed: %v", err)
+		}
+		if got := IOC(NewWarmupContextual).ctx.Value(warmupKey{}); got != "warmup" {
+			t.Errorf("Expected factories to see the warmup context, got %v", got)
+		}
+	})
+}
+
+// Async test types
+type AsyncIndex struct {
+	entries int
+}
+
+var asyncIndexBuilds atomic.Int32
+
+func NewAsyncIndex() *AsyncIndex {
+	asyncIndexBuilds.Add(1)
+	time.Sleep(30 * time.Millisecond)
+	return &AsyncIndex{entries: 42}
+}
+
+var asyncFailures atomic.Int32
+
+func NewFailingAsyncIndex() *AsyncIndex {
+	if asyncFailures.Add(1) == 1 {
+		panic("index unavailable")
+	}
+	return &AsyncIndex{entries: 1}
+}
+
+func NewSlowAsyncIndex() *AsyncIndex {
+	time.Sleep(200 * time.Millisecond)
+	return &AsyncIndex{}
+}
+
+// TestIOCAsync tests background construction of singletons
+func TestIOCAsync(t *testing.T) {
+	t.Run("Await Value", func(t *testing.T) {
+		ClearInstances()
+		asyncIndexBuilds.Store(0)
+
+		future := IOCAsync(NewAsyncIndex)
+		if IOCAsync(NewAsyncIndex) != future {
+			t.Error("Expected the in-flight future to be cached")
+		}
+
+		index, err := future.Await(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if index.entries != 42 {
+			t.Errorf("Expected 42 entries, got %d", index.entries)
+		}
+		if IOC(NewAsyncIndex) != index {
+			t.Error("Expected IOC to return the instance built in the background")
+		}
+		if builds := asyncIndexBuilds.Load(); builds != 1 {
+			t.Errorf("Expected one build, got %d", builds)
+		}
+	})
+
+	t.Run("Error Propagation", func(t *testing.T) {
+		ClearInstances()
+		asyncFailures.Store(0)
+
+		future := IOCAsync(NewFailingAsyncIndex)
+		_, err := future.Await(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "index unavailable") {
+			t.Fatalf("Expected factory error, got %v", err)
+		}
+
+		retry := IOCAsync(NewFailingAsyncIndex)
+		if retry == future {
+			t.Fatal("Expected a failed future to be dropped from the cache")
+		}
+		if index, err := retry.Await(context.Background()); err != nil || index.entries != 1 {
+			t.Errorf("Expected retry to succeed, got %v, %v", index, err)
+		}
+	})
+
+	t.Run("Immediate Failure", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			ClearInstances()
+			asyncFailures.Store(0)
+
+			future := IOCAsync(NewFailingAsyncIndex)
+			<-future.Done()
+			retry := IOCAsync(NewFailingAsyncIndex)
+			if retry == future {
+				t.Fatal("Expected a future failing before it was cached to be dropped")
+			}
+			<-retry.Done()
+		}
+	})
+
+	t.Run("Resolution Context", func(t *testing.T) {
+		ClearInstances()
+
+		type ctxKey struct{}
+		ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+		outer := IOCCtx(ctx, NewAsyncCtxOuter)
+		nested, err := outer.nested.Await(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if nested.sawCtx.Value(ctxKey{}) != "request" {
+			t.Error("Expected the background factory to see the caller's resolution context")
+		}
+
+		ClearInstances()
+		canceled, cancel := context.WithCancel(context.Background())
+		cancel()
+		restore := enterResolutionContext(canceled)
+		future := IOCAsync(NewCtxNested)
+		restore()
+		if _, err := future.Await(context.Background()); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the canceled resolution context to fail the future, got %v", err)
+		}
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		ClearInstances()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		future := IOCAsync(NewSlowAsyncIndex)
+		if _, err := future.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+
+		<-future.Done()
+		if _, err := future.Await(context.Background()); err != nil {
+			t.Errorf("Expected construction to finish after cancellation, got %v", err)
+		}
+	})
+}
+
+// Context-aware factory test types
+type CtxCatalog struct {
+	deadline bool
+	initCtx  context.Context
+}
+
+func (c *CtxCatalog) Init(ctx context.Context) error {
+	c.initCtx = ctx
+	return nil
+}
+
+func NewCtxCatalog(ctx context.Context) *CtxCatalog {
+	_, hasDeadline := ctx.Deadline()
+	return &CtxCatalog{deadline: hasDeadline}
+}
+
+type CtxNested struct {
+	sawCtx context.Context
+}
+
+func NewCtxNested() *CtxNested {
+	return &CtxNested{sawCtx: ResolutionContext()}
+}
+
+type CtxOuter struct {
+	nested *CtxNested
+}
+
+func NewCtxOuter(ctx context.Context) *CtxOuter {
+	return &CtxOuter{nested: IOC(NewCtxNested)}
+}
+
+type AsyncCtxOuter struct {
+	nested *Future[*CtxNested]
+}
+
+func NewAsyncCtxOuter(ctx context.Context) *AsyncCtxOuter {
+	return &AsyncCtxOuter{nested: IOCAsync(NewCtxNested)}
+}
+
+type CtxDatabase struct{}
+
+var ctxDatabaseAttempts atomic.Int32
+
+func NewCtxDatabase(ctx context.Context) (*CtxDatabase, error) {
+	if ctxDatabaseAttempts.Add(1) == 1 {
+		return nil, errors.New("connection refused")
+	}
+	return &CtxDatabase{}, nil
+}
+
+// NewLateCtxResource ignores its context and finishes after the provider timeout
+func NewLateCtxResource(ctx context.Context) (*ClosableResource, error) {
+	time.Sleep(30 * time.Millisecond)
+	return &ClosableResource{name: "late"}, nil
+}
+
+func NewSlowCtxDatabase(ctx context.Context) (*CtxDatabase, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Second):
+		return &CtxDatabase{}, nil
+	}
+}
+
+type ctxKey struct{}
+
+// TestIOCCtx tests context-aware factories
+func TestIOCCtx(t *testing.T) {
+	t.Run("Context Flows To Factories", func(t *testing.T) {
+		ClearInstances()
+
+		ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "request"), time.Minute)
+		defer cancel()
+
+		catalog := IOCCtx(ctx, NewCtxCatalog)
+		if !catalog.deadline {
+			t.Error("Expected the factory to see the deadline")
+		}
+		if catalog.initCtx == nil || catalog.initCtx.Value(ctxKey{}) != "request" {
+			t.Error("Expected Init to receive the resolution context")
+		}
+		if IOCCtx(context.Background(), NewCtxCatalog) != catalog {
+			t.Error("Expected the singleton to be cached")
+		}
+
+		outer := IOCCtx(ctx, NewCtxOuter)
+		if outer.nested.sawCtx.Value(ctxKey{}) != "request" {
+			t.Error("Expected nested resolutions to see the context")
+		}
+		if ResolutionContext() != context.Background() {
+			t.Error("Expected the resolution context to be reset afterwards")
+		}
+	})
+
+	t.Run("Errors Are Returned And Not Cached", func(t *testing.T) {
+		ClearInstances()
+		ctxDatabaseAttempts.Store(0)
+
+		if _, err := IOCCtxErr(context.Background(), NewCtxDatabase); err == nil || !strings.Contains(err.Error(), "connection refused") {
+			t.Fatalf("Expected factory error, got %v", err)
+		}
+		if len(getCurrentResolutionPath()) != 0 {
+			t.Error("Expected the resolution path to be restored")
+		}
+		db, err := IOCCtxErr(context.Background(), NewCtxDatabase)
+		if err != nil || db == nil {
+			t.Fatalf("Expected retry to succeed, got %v", err)
+		}
+	})
+
+	t.Run("Cancelled Context", func(t *testing.T) {
+		ClearInstances()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := IOCCtxErr(ctx, NewSlowCtxDatabase); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context canceled, got %v", err)
+		}
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expected IOCCtx to panic on a cancelled context")
+			}
+		}()
+		IOCCtx(ctx, NewCtxCatalog)
+	})
+
+	t.Run("Provider Timeout", func(t *testing.T) {
+		ClearInstances()
+		Configure(WithProviderTimeout(NewSlowCtxDatabase, 20*time.Millisecond))
+		defer Configure(WithProviderTimeout(NewSlowCtxDatabase, 0))
+
+		started := time.Now()
+		_, err := IOCCtxErr(context.Background(), NewSlowCtxDatabase)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+		if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+			t.Errorf("Expected the timeout to stop construction, took %v", elapsed)
+		}
+	})
+
+	t.Run("Late Instance", func(t *testing.T) {
+		ClearInstances()
+		lifecycleLog = nil
+		Configure(WithProviderTimeout(NewLateCtxResource, 10*time.Millisecond))
+		defer Configure(WithProviderTimeout(NewLateCtxResource, 0))
+
+		if _, err := IOCCtxErr(context.Background(), NewLateCtxResource); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+		if fmt.Sprint(lifecycleLog) != "[close late]" {
+			t.Errorf("Expected the instance finished after the deadline to be closed, got %v", lifecycleLog)
+		}
+	})
+
+	t.Run("Context Parameters", func(t *testing.T) {
+		ClearInstances()
+
+		ctx := context.WithValue(context.Background(), ctxKey{}, "injected")
+		outer := IOCCtx(ctx, func(ctx context.Context) *CtxCatalog {
+			return InjectConstructor[*CtxCatalog](func(ctx context.Context) *CtxCatalog {
+				return &CtxCatalog{initCtx: ctx}
+			})
+		}, Transient)
+		if outer.initCtx.Value(ctxKey{}) != "injected" {
+			t.Error("Expected the context parameter to receive the resolution context")
+		}
+	})
+}
+
+// Event test types
+type EventService struct{}
+
+func NewEventService() *EventService {
+	return &EventService{}
+}
+
+// TestAddListener tests container event listeners
+func TestAddListener(t *testing.T) {
+	var (
+		eventsMu sync.Mutex
+		events   []Event
+	)
+	record := func() func() {
+		eventsMu.Lock()
+		events = nil
+		eventsMu.Unlock()
+		return AddListener(ListenerFunc(func(e Event) {
+			eventsMu.Lock()
+			events = append(events, e)
+			eventsMu.Unlock()
+		}))
+	}
+	kinds := func() []EventKind {
+		eventsMu.Lock()
+		defer eventsMu.Unlock()
+		result := make([]EventKind, len(events))
+		for i, e := range events {
+			result[i] = e.Kind
+		}
+		return result
+	}
+
+	t.Run("Resolutions", func(t *testing.T) {
+		ClearInstances()
+		remove := record()
+		defer remove()
+
+		IOC(NewEventService)
+		IOC(NewEventService)
+
+		got := kinds()
+		if len(got) != 4 || got[0] != EventResolutionStarted || got[1] != EventResolutionFinished {
+			t.Fatalf("Unexpected events: %v", got)
+		}
+		if events[1].CacheHit || !events[3].CacheHit {
+			t.Error("Expected a cache miss followed by a cache hit")
+		}
+		if events[1].Type != reflect.TypeOf(&EventService{}) || events[1].Scope != Singleton {
+			t.Errorf("Unexpected finished event: %+v", events[1])
+		}
+		if !strings.Contains(events[1].Name, "NewEventService") {
+			t.Errorf("Expected factory name, got %s", events[1].Name)
+		}
+	})
+
+	t.Run("Scopes And Registrations", func(t *testing.T) {
+		ClearInstances()
+		remove := record()
+		defer remove()
+
+		cleanup := BeginScope()
+		scopeID := GetActiveScope()
+		cleanup()
+		RegisterInstance(&EventService{})
+
+		got := kinds()
+		want := []EventKind{EventScopeOpened, EventScopeClosed, EventProviderRegistered}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+		if string(events[0].ScopeID) != scopeID || events[1].ScopeID != events[0].ScopeID {
+			t.Errorf("Expected scope events for %s, got %s and %s", scopeID, events[0].ScopeID, events[1].ScopeID)
+		}
+	})
+
+	t.Run("Cycles And Disposal", func(t *testing.T) {
+		ClearInstances()
+		remove := record()
+
+		func() {
+			defer func() { recover() }()
+			IOC(NewCircularServiceAFactory)
+		}()
+		IOC(func() *ClosableResource { return &ClosableResource{name: "events"} })
+		if err := Shutdown(context.Background()); err != nil {
+			t.Fatalf("Unexpected shutdown error: %v", err)
+		}
+		remove()
+		IOC(NewEventService)
+
+		var cycle, disposed, failed bool
+		for _, e := range events {
+			switch {
+			case e.Kind == EventCycleDetected:
+				cycle = e.Path != ""
+			case e.Kind == EventInstanceDisposed:
+				disposed = e.Type == reflect.TypeOf(&ClosableResource{})
+			case e.Kind == EventResolutionFinished && e.Err != nil:
+				failed = true
+			}
+		}
+		if !cycle || !disposed || !failed {
+			t.Errorf("Expected cycle, disposal and failed resolution events, got %v", kinds())
+		}
+		for _, e := range events {
+			if strings.Contains(e.Name, "NewEventService") {
+				t.Error("Expected no events after the listener was removed")
+			}
+		}
+	})
+
+	t.Run("Goexit", func(t *testing.T) {
+		ClearInstances()
+		remove := record()
+		defer remove()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			IOC(func() *EventService {
+				runtime.Goexit()
+				return nil
+			}, Transient)
+		}()
+		<-done
+
+		got := kinds()
+		if len(got) != 2 || got[1] != EventResolutionFinished {
+			t.Fatalf("Unexpected events: %v", got)
+		}
+		if events[1].Err != nil {
+			t.Errorf("Expected no error for runtime.Goexit, got %v", events[1].Err)
+		}
+	})
+}
+
+// Logger test types
+type LoggedService struct{}
+
+func NewLoggedService() *LoggedService {
+	return &LoggedService{}
+}
+
+func NewFailingLoggedService() *LoggedService {
+	panic("logged service unavailable")
+}
+
+// TestWithLogger tests structured container diagnostics
+func TestWithLogger(t *testing.T) {
+	t.Run("Structured Records", func(t *testing.T) {
+		ClearInstances()
+
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		Configure(WithLogger(logger))
+		defer Configure(WithLogger(nil))
+
+		IOC(NewLoggedService)
+		func() {
+			defer func() { recover() }()
+			IOC(NewCircularServiceAFactory)
+		}()
+
+		var resolved, cycle bool
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("Expected JSON records, got %q", line)
+			}
+			switch record["msg"] {
+			case "gioc resolved":
+				if strings.Contains(fmt.Sprint(record["provider"]), "NewLoggedService") && record["level"] == "DEBUG" {
+					resolved = true
+				}
+			case "gioc circular dependency":
+				cycle = record["level"] == "ERROR"
+			}
+		}
+		if !resolved || !cycle {
+			t.Errorf("Expected resolution and cycle records, got:\n%s", buf.String())
+		}
+	})
+
+	t.Run("Failures Above Debug Level", func(t *testing.T) {
+		ClearInstances()
+
+		var buf bytes.Buffer
+		Configure(WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+		defer Configure(WithLogger(nil))
+
+		IOC(NewLoggedService)
+		IOC(NewLoggedService)
+		if buf.Len() != 0 {
+			t.Errorf("Expected no records for successful resolutions, got %q", buf.String())
+		}
+
+		func() {
+			defer func() { recover() }()
+			IOC(NewFailingLoggedService)
+		}()
+		if !strings.Contains(buf.String(), "level=ERROR") || !strings.Contains(buf.String(), "NewFailingLoggedService") {
+			t.Errorf("Expected the failure to be logged, got %q", buf.String())
+		}
+	})
+
+	t.Run("Warnings", func(t *testing.T) {
+		var buf bytes.Buffer
+		Configure(WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+		defer Configure(WithLogger(nil))
+
+		warnf("something odd about %s", "factory")
+		if !strings.Contains(buf.String(), "level=WARN") || !strings.Contains(buf.String(), "something odd about factory") {
+			t.Errorf("Expected warning record, got %q", buf.String())
+		}
+	})
+
+	t.Run("Writer And Slog Variants", func(t *testing.T) {
+		ClearInstances()
+		IOC(NewLoggedService)
+
+		var buf bytes.Buffer
+		ListInstancesTo(&buf)
+		if !strings.Contains(buf.String(), "*gioc.LoggedService") {
+			t.Errorf("Expected instance listing, got %q", buf.String())
+		}
+
+		buf.Reset()
+		ListScopedInstancesTo(&buf)
+		if !strings.Contains(buf.String(), "No active scope") {
+			t.Errorf("Expected no active scope, got %q", buf.String())
+		}
+
+		buf.Reset()
+		LogInstances(slog.New(slog.NewTextHandler(&buf, nil)))
+		if !strings.Contains(buf.String(), "type=*gioc.LoggedService") {
+			t.Errorf("Expected instance record, got %q", buf.String())
+		}
+	})
+}
+
+// Describe test types
+type DescribedRepo struct{}
+
+func NewDescribedRepo() *DescribedRepo {
+	return &DescribedRepo{}
+}
+
+type DescribedService struct {
+	repo *DescribedRepo
+}
+
+func NewDescribedService() *DescribedService {
+	return &DescribedService{repo: IOC(NewDescribedRepo)}
+}
+
+// TestDescribe tests the introspection API
+func TestDescribe(t *testing.T) {
+	ClearInstances()
+
+	IOC(NewDescribedService)
+	IOC(NewDescribedService)
+	RegisterInstance(&DescribedRepo{})
+	RegisterType(&DescribedService{})
+
+	cleanup := BeginScope()
+	defer cleanup()
+	IOC(NewDescribedRepo, Scoped)
+
+	descriptors := Describe()
+	if len(descriptors) != 5 {
+		t.Fatalf("Expected 5 descriptors, got %d: %+v", len(descriptors), descriptors)
+	}
+
+	wantKinds := []DescriptorKind{KindSingleton, KindSingleton, KindScoped, KindInstance, KindType}
+	for i, d := range descriptors {
+		if d.Kind != wantKinds[i] {
+			t.Errorf("Descriptor %d: expected kind %s, got %s", i, wantKinds[i], d.Kind)
+		}
+	}
+	if descriptors[0].Key > descriptors[1].Key {
+		t.Error("Expected descriptors to be sorted by key")
+	}
+
+	var service Descriptor
+	for _, d := range descriptors {
+		if d.Kind == KindSingleton && strings.HasSuffix(d.Key, "NewDescribedService") {
+			service = d
+		}
+	}
+	if service.Type != "*gioc.DescribedService" || service.Scope != "Singleton" {
+		t.Errorf("Unexpected service descriptor: %+v", service)
+	}
+	if service.Resolutions != 2 {
+		t.Errorf("Expected 2 resolutions, got %d", service.Resolutions)
+	}
+	if !strings.Contains(service.Source, "gioc_test.go:") || service.CreatedAt.IsZero() {
+		t.Errorf("Expected source position and creation time, got %+v", service)
+	}
+	if len(service.Dependencies) != 1 || !strings.HasSuffix(service.Dependencies[0], "NewDescribedRepo") {
+		t.Errorf("Expected dependency on NewDescribedRepo, got %v", service.Dependencies)
+	}
+
+	// Listings are sorted, so they are stable across calls
+	var first, second bytes.Buffer
+	ListInstancesTo(&first)
+	ListInstancesTo(&second)
+	if first.String() != second.String() {
+		t.Error("Expected deterministic listing output")
+	}
+	if strings.Index(first.String(), "NewDescribedRepo") > strings.Index(first.String(), "NewDescribedService") {
+		t.Errorf("Expected sorted listing, got %q", first.String())
+	}
+}
+
+// Metrics test types
+type MeteredService struct{}
+
+func NewMeteredService() *MeteredService {
+	time.Sleep(2 * time.Millisecond)
+	return &MeteredService{}
+}
+
+type FailingDisposable struct{}
+
+func (d *FailingDisposable) Dispose() error {
+	return errors.New("still in use")
+}
+
+// TestMetrics tests metric collection and exposition
+func TestMetrics(t *testing.T) {
+	ClearInstances()
+	Configure(WithMetrics(true))
+	defer Configure(WithMetrics(false))
+
+	IOC(NewMeteredService)
+	IOC(NewMeteredService)
+	IOC(NewMeteredService)
+	func() {
+		defer func() { recover() }()
+		IOC(NewCircularServiceAFactory)
+	}()
+	IOC(func() *FailingDisposable { return &FailingDisposable{} })
+	IOCFor(NewTenantDB, "metrics-a")
+	IOCFor(NewTenantDB, "metrics-b")
+	cleanup := BeginScope()
+
+	_ = Shutdown(context.Background())
+
+	snapshot := Metrics()
+	snapshot.LatencyBounds[0] = 0
+	if Metrics().LatencyBounds[0] != time.Millisecond {
+		t.Error("Expected the latency bounds of a snapshot to be a copy")
+	}
+	if snapshot.ActiveScopes < 1 || snapshot.DisposalErrors != 1 {
+		t.Errorf("Expected an active scope and a disposal error, got %+v", snapshot)
+	}
+	cleanup()
+
+	var service, tenants ProviderMetrics
+	var failures int64
+	for _, provider := range snapshot.Providers {
+		switch {
+		case strings.HasSuffix(provider.Provider, "NewMeteredService"):
+			service = provider
+		case strings.HasSuffix(provider.Provider, "NewTenantDB"):
+			tenants = provider
+		case strings.Contains(provider.Provider, "metrics-"):
+			t.Errorf("Expected keyed instances to be counted under their factory, got %s", provider.Provider)
+		}
+		failures += provider.Failures
+	}
+	if tenants.Constructions != 2 {
+		t.Errorf("Expected both keys of NewTenantDB under one provider, got %+v", tenants)
+	}
+	if service.Resolutions != 3 || service.CacheHits != 2 || service.Constructions != 1 {
+		t.Errorf("Unexpected provider metrics: %+v", service)
+	}
+	if service.LatencySum < 2*time.Millisecond || service.LatencyBuckets[0] != 0 || service.LatencyBuckets[len(service.LatencyBuckets)-1] != 1 {
+		t.Errorf("Unexpected latency histogram: %+v", service)
+	}
+	if failures == 0 {
+		t.Error("Expected the cycle to be counted as a failure")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMetrics(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"# TYPE gioc_resolutions_total counter",
+		`gioc_cache_hits_total{provider="github.com/mstgnz/gioc.NewMeteredService"} 2`,
+		`gioc_construction_duration_seconds_bucket{provider="github.com/mstgnz/gioc.NewMeteredService",le="+Inf"} 1`,
+		"gioc_disposal_errors_total 1",
+		"# TYPE gioc_active_scopes gauge",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in metrics output:\n%s", want, buf.String())
+		}
+	}
+
+	PublishExpvar()
+	PublishExpvar()
+	if v := expvar.Get("gioc"); v == nil || !strings.Contains(v.String(), "NewMeteredService") {
+		t.Errorf("Expected expvar variable, got %v", v)
+	}
+}
+
+// Startup report test types
+type ReportConfig struct{}
+
+func NewReportConfig() *ReportConfig {
+	time.Sleep(30 * time.Millisecond)
+	return &ReportConfig{}
+}
+
+type ReportCache struct{}
+
+func NewReportCache() *ReportCache {
+	time.Sleep(5 * time.Millisecond)
+	return &ReportCache{}
+}
+
+type ReportApp struct{}
+
+func NewReportApp() *ReportApp {
+	IOC(NewReportConfig)
+	IOC(NewReportCache)
+	time.Sleep(10 * time.Millisecond)
+	return &ReportApp{}
+}
+
+// Contended factory test types
+type ContendedChild struct{}
+
+var contendedChildBuilding = make(chan struct{}, 1)
+
+func NewContendedChild() *ContendedChild {
+	contendedChildBuilding <- struct{}{}
+	time.Sleep(50 * time.Millisecond)
+	return &ContendedChild{}
+}
+
+type ContendedParent struct {
+	child *ContendedChild
+}
+
+func NewContendedParent() *ContendedParent {
+	return &ContendedParent{child: IOC(NewContendedChild)}
+}
+
+type ReportTask struct {
+	id int
+}
+
+func NewReportTask() *ReportTask {
+	return &ReportTask{}
+}
+
+// TestSlowFactories tests slow factory detection and the startup report
+func TestSlowFactories(t *testing.T) {
+	t.Run("Threshold Warnings", func(t *testing.T) {
+		ClearInstances()
+		Configure(WithSlowFactoryThreshold(20 * time.Millisecond))
+		defer Configure(WithSlowFactoryThreshold(0))
+
+		var slow []string
+		remove := AddListener(ListenerFunc(func(e Event) {
+			if e.Kind == EventSlowFactory {
+				slow = append(slow, e.Name)
+			}
+		}))
+		defer remove()
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(os.Stderr)
+
+		IOC(NewReportApp)
+
+		// Only the config exceeds the threshold once nested factories are excluded
+		if len(slow) != 1 || !strings.HasSuffix(slow[0], "NewReportConfig") {
+			t.Errorf("Expected only NewReportConfig to be slow, got %v", slow)
+		}
+		if !strings.Contains(buf.String(), "NewReportConfig") {
+			t.Errorf("Expected a warning without a configured logger, got %q", buf.String())
+		}
+	})
+
+	t.Run("Direct And Constructor Injection", func(t *testing.T) {
+		ClearInstances()
+		Configure(WithSlowFactoryThreshold(20 * time.Millisecond))
+		defer Configure(WithSlowFactoryThreshold(0))
+
+		var slow []string
+		remove := AddListener(ListenerFunc(func(e Event) {
+			if e.Kind == EventSlowFactory {
+				slow = append(slow, e.Name)
+			}
+		}))
+		defer remove()
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(os.Stderr)
+
+		DirectIOC(NewReportConfig)
+		InjectConstructor[*ReportConfig](NewReportConfig)
+		if len(slow) != 2 {
+			t.Errorf("Expected DirectIOC and InjectConstructor to be timed, got %v", slow)
+		}
+	})
+
+	t.Run("Startup Report", func(t *testing.T) {
+		ClearInstances()
+		Configure(WithSlowFactoryThreshold(-1))
+		defer Configure(WithSlowFactoryThreshold(0))
+
+		IOC(NewReportApp)
+		report := StartupReport()
+
+		if len(report.Components) != 3 || !strings.HasSuffix(report.Components[0].Name, "NewReportConfig") {
+			t.Fatalf("Expected 3 components led by NewReportConfig, got %+v", report.Components)
+		}
+		if len(report.CriticalPath) != 2 ||
+			!strings.HasSuffix(report.CriticalPath[0].Name, "NewReportApp") ||
+			!strings.HasSuffix(report.CriticalPath[1].Name, "NewReportConfig") {
+			t.Fatalf("Expected critical path app -> config, got %+v", report.CriticalPath)
+		}
+		app := report.CriticalPath[0]
+		if app.Self >= app.Duration || app.Self < 10*time.Millisecond {
+			t.Errorf("Expected self time to exclude nested factories, got self %v total %v", app.Self, app.Duration)
+		}
+		if report.CriticalPathDuration < 40*time.Millisecond || report.CriticalPathDuration > report.Total {
+			t.Errorf("Unexpected critical path duration %v (total %v)", report.CriticalPathDuration, report.Total)
+		}
+		if !strings.Contains(report.String(), "Critical path") {
+			t.Errorf("Unexpected report text: %s", report)
+		}
+	})
+
+	t.Run("Waiting Is Not Self Time", func(t *testing.T) {
+		ClearInstances()
+		Configure(WithSlowFactoryThreshold(-1))
+		defer Configure(WithSlowFactoryThreshold(0))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			IOC(NewContendedChild)
+		}()
+		<-contendedChildBuilding
+		IOC(NewContendedParent)
+		<-done
+
+		for _, timing := range StartupReport().Components {
+			if strings.HasSuffix(timing.Name, "NewContendedParent") && timing.Self >= 25*time.Millisecond {
+				t.Errorf("Expected the wait for the child to be excluded, got self %v total %v", timing.Self, timing.Duration)
+			}
+		}
+	})
+
+	t.Run("Startup Window", func(t *testing.T) {
+		ClearInstances()
+		Configure(WithSlowFactoryThreshold(-1))
+		defer Configure(WithSlowFactoryThreshold(0))
+
+		IOC(NewReportTask, Transient)
+		IOC(NewReportTask, Transient)
+		report := StartupReport()
+		if len(report.Components) != 1 || report.Components[0].Calls != 2 {
+			t.Fatalf("Expected two calls of NewReportTask, got %+v", report.Components)
+		}
+
+		// Factory calls after Start are not timed
+		if err := Start(context.Background()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		IOC(NewReportTask, Transient)
+		IOC(NewReportConfig)
+		if report := StartupReport(); len(report.Components) != 1 || report.Components[0].Calls != 2 {
+			t.Errorf("Expected no timings after Start, got %+v", report.Components)
+		}
+
+		// An explicit threshold keeps timing factory calls
+		Configure(WithSlowFactoryThreshold(time.Hour))
+		IOC(NewReportTask, Transient)
+		if report := StartupReport(); report.Components[0].Calls != 3 {
+			t.Errorf("Expected an explicit threshold to time factories, got %+v", report.Components)
+		}
+	})
+}
+
+// Profiling test types
+type ProfiledDependency struct {
+	provider string
+	request  string
+}
+
+func NewProfiledDependency() *ProfiledDependency {
+	provider, _ := pprof.Label(ResolutionContext(), "gioc.provider")
+	request, _ := pprof.Label(ResolutionContext(), "request")
+	return &ProfiledDependency{provider: provider, request: request}
+}
+
+type ProfiledService struct {
+	dependency *ProfiledDependency
+	provider   string
+	scope      string
+}
+
+func NewProfiledService() *ProfiledService {
+	dependency := IOC(NewProfiledDependency)
+	// Labels of the dependent are restored once the dependency is built
+	provider, _ := pprof.Label(ResolutionContext(), "gioc.provider")
+	scope, _ := pprof.Label(ResolutionContext(), "gioc.scope")
+	return &ProfiledService{dependency: dependency, provider: provider, scope: scope}
+}
+
+// TestWithProfiling tests trace regions and pprof labels around factories
+func TestWithProfiling(t *testing.T) {
+	t.Run("Labels", func(t *testing.T) {
+		ClearInstances()
+		Configure(WithProfiling(true))
+		defer Configure(WithProfiling(false))
+
+		service := IOC(NewProfiledService)
+		if !strings.HasSuffix(service.provider, "NewProfiledService") || service.scope != "Singleton" {
+			t.Errorf("Unexpected labels for the service: %q, %q", service.provider, service.scope)
+		}
+		if !strings.HasSuffix(service.dependency.provider, "NewProfiledDependency") {
+			t.Errorf("Unexpected labels for the dependency: %q", service.dependency.provider)
+		}
+	})
+
+	t.Run("Caller Labels", func(t *testing.T) {
+		ClearInstances()
+		Configure(WithProfiling(true))
+		defer Configure(WithProfiling(false))
+
+		var dependency *ProfiledDependency
+		pprof.Do(context.Background(), pprof.Labels("request", "r1"), func(ctx context.Context) {
+			dependency = IOCCtx(ctx, func(ctx context.Context) *ProfiledService {
+				return &ProfiledService{dependency: IOC(NewProfiledDependency)}
+			}).dependency
+		})
+		if dependency.request != "r1" || !strings.HasSuffix(dependency.provider, "NewProfiledDependency") {
+			t.Errorf("Expected the caller's labels next to the gioc labels, got %+v", dependency)
+		}
+	})
+
+	t.Run("Trace Regions", func(t *testing.T) {
+		ClearInstances()
+		Configure(WithProfiling(true))
+		defer Configure(WithProfiling(false))
+
+		var buf bytes.Buffer
+		if err := trace.Start(&buf); err != nil {
+			t.Skipf("tracing unavailable: %v", err)
+		}
+		IOC(NewProfiledService)
+		trace.Stop()
+
+		if !bytes.Contains(buf.Bytes(), []byte("NewProfiledDependency")) {
+			t.Error("Expected a trace region for the dependency")
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		ClearInstances()
+
+		if service := IOC(NewProfiledService); service.provider != "" {
+			t.Errorf("Expected no labels when profiling is disabled, got %q", service.provider)
+		}
+	})
+}
+
+type DiagnosticLeft struct{ right *DiagnosticRight }
+
+type DiagnosticRight struct{ left *DiagnosticLeft }
+
+var newDiagnosticLeft, newDiagnosticRight func() any
+
+func init() {
+	newDiagnosticLeft = func() any { return &DiagnosticLeft{right: IOC(newDiagnosticRight).(*DiagnosticRight)} }
+	newDiagnosticRight = func() any { return &DiagnosticRight{left: IOC(newDiagnosticLeft).(*DiagnosticLeft)} }
+}
+
+type DiagnosticStore struct{}
+
+type DiagnosticStores struct{}
+
+type DiagnosticHandler struct{}
+
+func NewDiagnosticHandler(stores *DiagnosticStores) *DiagnosticHandler {
+	return &DiagnosticHandler{}
+}
+
+// TestDiagnostics tests cycle reports and missing dependency suggestions
+func TestDiagnostics(t *testing.T) {
+	t.Run("Cycle Report", func(t *testing.T) {
+		ClearInstances()
+
+		message := func() (message string) {
+			defer func() { message, _ = recover().(string) }()
+			IOC(newDiagnosticLeft)
+			return ""
+		}()
+
+		lines := strings.Split(message, "\n\t")
+		if len(lines) != 4 || lines[0] != "circular dependency detected:" {
+			t.Fatalf("Expected one line per hop, got %q", message)
+		}
+		if !strings.Contains(lines[1], "func1 (gioc_test.go:") || !strings.Contains(lines[1], "-> interface {} [Singleton], registered at gioc_test.go:") {
+			t.Errorf("Unexpected first hop: %q", lines[1])
+		}
+		if !strings.Contains(lines[2], "func2 (gioc_test.go:") {
+			t.Errorf("Unexpected second hop: %q", lines[2])
+		}
+		if lines[3] != lines[1] {
+			t.Errorf("Expected the cycle to close on the first hop, got %q", lines[3])
+		}
+	})
+
+	t.Run("Missing Dependency", func(t *testing.T) {
+		ClearInstances()
+		RegisterType(&DiagnosticStore{})
+
+		message := func() (message string) {
+			defer func() { message, _ = recover().(string) }()
+			InjectConstructor[*DiagnosticHandler](NewDiagnosticHandler)
+			return ""
+		}()
+
+		if !strings.HasPrefix(message, "no dependency found for parameter") ||
+			!strings.Contains(message, "of type *gioc.DiagnosticStores in github.com/mstgnz/gioc.NewDiagnosticHandler (gioc_test.go:") {
+			t.Errorf("Unexpected report: %q", message)
+		}
+		if !strings.Contains(message, "did you mean *gioc.DiagnosticStore (registered with RegisterType)?") {
+			t.Errorf("Expected a suggestion, got %q", message)
+		}
+	})
+
+	t.Run("GetType Suggestion", func(t *testing.T) {
+		ClearInstances()
+		RegisterType(&DiagnosticStore{})
+
+		message := func() (message string) {
+			defer func() { message, _ = recover().(string) }()
+			GetType[DiagnosticStore]()
+			return ""
+		}()
+
+		if !strings.Contains(message, "did you mean *gioc.DiagnosticStore (registered with RegisterType)?") {
+			t.Errorf("Expected a suggestion, got %q", message)
+		}
+	})
+	t.Run("GetInstance Suggestion", func(t *testing.T) {
+		ClearInstances()
+		RegisterInstance(&DiagnosticStore{})
+
+		message := func() (message string) {
+			defer func() { message, _ = recover().(string) }()
+			GetInstance[DiagnosticStore]()
+			return ""
+		}()
+
+		if !strings.HasPrefix(message, "no instance registered for type gioc.DiagnosticStore") ||
+			!strings.Contains(message, "did you mean *gioc.DiagnosticStore") {
+			t.Errorf("Expected a suggestion, got %q", message)
+		}
+	})
+}
+
+type ExplainDB struct{}
+
+func NewExplainDB() *ExplainDB {
+	return &ExplainDB{}
+}
+
+type ExplainRepo struct{ db *ExplainDB }
+
+func NewExplainRepo() *ExplainRepo {
+	return &ExplainRepo{db: IOC(NewExplainDB)}
+}
+
+type ExplainServer struct{ repo *ExplainRepo }
+
+func NewExplainServer() *ExplainServer {
+	return &ExplainServer{repo: IOC(NewExplainRepo)}
+}
+
+type ExplainWorker struct{ db *ExplainDB }
+
+func NewExplainWorker() *ExplainWorker {
+	return &ExplainWorker{db: IOC(NewExplainDB)}
+}
+
+type ExplainUnused struct{}
+
+func NewExplainUnused() *ExplainUnused {
+	return &ExplainUnused{}
+}
+
+// TestExplain tests the resolution chains reported by Explain
+func TestExplain(t *testing.T) {
+	t.Run("Chains", func(t *testing.T) {
+		ClearInstances()
+		IOC(NewExplainServer)
+		IOC(NewExplainWorker)
+
+		explanation := Explain[*ExplainDB]()
+		if len(explanation.Components) != 1 || explanation.Components[0] != "github.com/mstgnz/gioc.NewExplainDB" {
+			t.Fatalf("Unexpected components: %v", explanation.Components)
+		}
+
+		expected := [][]string{
+			{"github.com/mstgnz/gioc.NewExplainServer", "github.com/mstgnz/gioc.NewExplainRepo", "github.com/mstgnz/gioc.NewExplainDB"},
+			{"github.com/mstgnz/gioc.NewExplainWorker", "github.com/mstgnz/gioc.NewExplainDB"},
+		}
+		if !reflect.DeepEqual(explanation.Chains, expected) {
+			t.Errorf("Expected chains %v, got %v", expected, explanation.Chains)
+		}
+		if !strings.Contains(explanation.String(), "NewExplainWorker -> github.com/mstgnz/gioc.NewExplainDB") {
+			t.Errorf("Unexpected text: %s", explanation)
+		}
+	})
+
+	t.Run("Root", func(t *testing.T) {
+		ClearInstances()
+		IOC(NewExplainServer)
+
+		explanation := Explain[*ExplainServer]()
+		if len(explanation.Chains) != 1 || len(explanation.Chains[0]) != 1 {
+			t.Errorf("Expected a root to explain itself, got %v", explanation.Chains)
+		}
+	})
+
+	t.Run("Shared Dependencies", func(t *testing.T) {
+		ClearInstances()
+		IOC(NewExplainDB)
+
+		// A ladder of 30 levels where both nodes of a level depend on both nodes of the
+		// next one has 2^30 chains
+		db := reflect.ValueOf(NewExplainDB).Pointer()
+		mu.Lock()
+		below := []uintptr{db}
+		for level := uintptr(1); level <= 30; level++ {
+			above := []uintptr{level * 2, level*2 + 1}
+			for _, parent := range above {
+				dependencyGraph[parent] = make(map[uintptr]bool)
+				for _, child := range below {
+					dependencyGraph[parent][child] = true
+				}
+			}
+			below = above
+		}
+		mu.Unlock()
+
+		explanation := Explain[*ExplainDB]()
+		if len(explanation.Chains) != maxExplainChains || !explanation.Truncated {
+			t.Fatalf("Expected %d chains and truncation, got %d", maxExplainChains, len(explanation.Chains))
+		}
+		if !strings.HasSuffix(explanation.String(), "more chains omitted\n") {
+			t.Errorf("Expected the text to mention omitted chains")
+		}
+		if again := Explain[*ExplainDB](); !reflect.DeepEqual(again.Chains, explanation.Chains) {
+			t.Error("Expected a truncated explanation to be stable")
+		}
+	})
+
+	t.Run("Not Instantiated", func(t *testing.T) {
+		ClearInstances()
+
+		explanation := Explain[*ExplainDB]()
+		if len(explanation.Components) != 0 || explanation.String() != "*gioc.ExplainDB was not instantiated\n" {
+			t.Errorf("Unexpected explanation: %q", explanation)
+		}
+	})
+
+	t.Run("Dead Providers", func(t *testing.T) {
+		ClearInstances()
+		Register(NewExplainDB)
+		Register(NewExplainUnused)
+		IOC(NewExplainWorker)
+
+		dead := DeadProviders()
+		if len(dead) != 1 || !strings.Contains(dead[0], "NewExplainUnused") {
+			t.Fatalf("Expected only NewExplainUnused to be dead, got %v", dead)
+		}
+		if !strings.Contains(dead[0], "-> *gioc.ExplainUnused [Singleton], registered at gioc_test.go:") {
+			t.Errorf("Unexpected description: %q", dead[0])
+		}
+
+		IOC(NewExplainUnused)
+		if dead := DeadProviders(); len(dead) != 0 {
+			t.Errorf("Expected no dead providers after resolution, got %v", dead)
+		}
+	})
+}
+
+type OverrideStore interface {
+	Name() string
+}
+
+type realStore struct{}
+
+func (*realStore) Name() string { return "real" }
+
+type fakeStore struct{}
+
+func (*fakeStore) Name() string { return "fake" }
+
+func NewRealStore() OverrideStore {
+	return &realStore{}
+}
+
+func NewFakeStore() OverrideStore {
+	return &fakeStore{}
+}
+
+type OverrideService struct {
+	store OverrideStore
+}
+
+func NewOverrideService() *OverrideService {
+	return &OverrideService{store: IOC(NewRealStore)}
+}
+
+func NewOverrideConsumer(store OverrideStore) *OverrideService {
+	return &OverrideService{store: store}
+}
+
+// TestOverride tests swapping implementations and restoring them
+func TestOverride(t *testing.T) {
+	t.Run("Factory", func(t *testing.T) {
+		ClearInstances()
+		before := IOC(NewOverrideService)
+
+		restore := Override(NewRealStore, NewFakeStore)
+		if name := IOC(NewOverrideService).store.Name(); name != "fake" {
+			t.Errorf("Expected the dependent to be rebuilt with the fake, got %s", name)
+		}
+		if name := DirectIOC(NewRealStore).Name(); name != "fake" {
+			t.Errorf("Expected DirectIOC to use the fake, got %s", name)
+		}
+		consumer := InjectConstructor[*OverrideService](NewOverrideConsumer, WithDependency("store", NewRealStore))
+		if consumer.store.Name() != "fake" {
+			t.Errorf("Expected WithDependency to use the fake, got %s", consumer.store.Name())
+		}
+
+		restore()
+		restore()
+		if after := IOC(NewOverrideService); after != before || after.store.Name() != "real" {
+			t.Error("Expected the original singletons to be restored")
+		}
+	})
+
+	t.Run("Instance", func(t *testing.T) {
+		ClearInstances()
+		RegisterType(OverrideStore(&realStore{}))
+
+		restore := OverrideInstance[OverrideStore](&fakeStore{})
+		if name := IOC(NewRealStore).Name(); name != "fake" {
+			t.Errorf("Expected IOC to return the fake, got %s", name)
+		}
+		if name := IOC(NewOverrideService).store.Name(); name != "fake" {
+			t.Errorf("Expected the dependent to get the fake, got %s", name)
+		}
+		if name := InjectConstructor[*OverrideService](NewOverrideConsumer).store.Name(); name != "fake" {
+			t.Errorf("Expected InjectConstructor to get the fake, got %s", name)
+		}
+		if name := GetInstance[OverrideStore]().Name(); name != "fake" {
+			t.Errorf("Expected GetInstance to return the fake, got %s", name)
+		}
+		if name := GetType[*realStore]().Name(); name != "real" {
+			t.Errorf("Expected other types to be unaffected, got %s", name)
+		}
+		restore()
+
+		if name := IOC(NewOverrideService).store.Name(); name != "real" {
+			t.Errorf("Expected the real store after restore, got %s", name)
+		}
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		ClearInstances()
+		first := OverrideInstance[OverrideStore](&fakeStore{})
+		second := OverrideInstance[OverrideStore](&realStore{})
+		if name := IOC(NewRealStore).Name(); name != "real" {
+			t.Errorf("Expected the latest override, got %s", name)
+		}
+		second()
+		if name := IOC(NewRealStore).Name(); name != "fake" {
+			t.Errorf("Expected the first override again, got %s", name)
+		}
+		first()
+	})
+
+	t.Run("Cleared", func(t *testing.T) {
+		ClearInstances()
+		restore := Override(NewRealStore, NewFakeStore)
+		ClearInstances()
+		if name := IOC(NewRealStore).Name(); name != "real" {
+			t.Errorf("Expected ClearInstances to drop overrides, got %s", name)
+		}
+		restore()
+		if count := GetInstanceCount(); count != 1 {
+			t.Errorf("Expected a stale restore to leave the container alone, got %d instances", count)
+		}
+	})
+
+	t.Run("Sealed", func(t *testing.T) {
+		ClearInstances()
+		defer Configure(WithProductionMode(false))
+		Configure(WithProductionMode(true))
+		if err := Validate(NewRealStore); err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "sealed in production mode") {
+				t.Errorf("Expected Override to be forbidden, got %v", r)
+			}
+		}()
+		Override(NewRealStore, NewFakeStore)
+	})
+}
+
+type SnapshotBase struct{ id int }
+
+var snapshotBuilds atomic.Int32
+
+func NewSnapshotBase() *SnapshotBase {
+	return &SnapshotBase{id: int(snapshotBuilds.Add(1))}
+}
+
+type SnapshotCase struct{ base *SnapshotBase }
+
+func NewSnapshotCase() *SnapshotCase {
+	return &SnapshotCase{base: IOC(NewSnapshotBase)}
+}
+
+func NewSnapshotResource() *ClosableResource {
+	return &ClosableResource{name: "created"}
+}
+
+// TestSnapshot tests restoring the container to a snapshot
+func TestSnapshot(t *testing.T) {
+	ClearInstances()
+	snapshotBuilds.Store(0)
+	base := IOC(NewSnapshotBase)
+	keyed := IOCKey("primary", NewSnapshotBase)
+	RegisterType(&SnapshotBase{id: -1})
+	Register(NewSnapshotCase)
+	snap := Snapshot()
+
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			defer Restore(snap)
+
+			if IOC(NewSnapshotBase) != base || IOCKey("primary", NewSnapshotBase) != keyed {
+				t.Error("Expected the singletons of the snapshot")
+			}
+			if count := GetInstanceCount(); count != 2 {
+				t.Errorf("Expected the 2 singletons of the snapshot, got %d", count)
+			}
+			if dead := DeadProviders(); len(dead) != 1 {
+				t.Errorf("Expected NewSnapshotCase to be unresolved, got %v", dead)
+			}
+
+			if IOC(NewSnapshotCase).base != base {
+				t.Error("Expected the case to share the base singleton")
+			}
+			IOCKey("secondary", NewSnapshotBase)
+			RegisterType(&SnapshotCase{})
+		})
+	}
+
+	if count := GetInstanceCount(); count != 2 {
+		t.Errorf("Expected the singletons created by the cases to be dropped, got %d", count)
+	}
+	if TypeCount() != 1 {
+		t.Errorf("Expected the registrations of the cases to be dropped, got %d", TypeCount())
+	}
+	if deps := Describe(); len(deps) != 3 {
+		t.Errorf("Expected 3 components after restore, got %d", len(deps))
+	}
+	if builds := snapshotBuilds.Load(); builds != 4 {
+		t.Errorf("Expected the base to be built for the snapshot and once per secondary key, got %d builds", builds)
+	}
+
+	t.Run("Disposal", func(t *testing.T) {
+		ClearInstances()
+		IOC(func() *ClosableResource { return &ClosableResource{name: "kept"} })
+		snap := Snapshot()
+		IOC(NewSnapshotResource)
+		lifecycleLog = nil
+
+		if err := Restore(snap); err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(lifecycleLog) != "[close created]" {
+			t.Errorf("Expected only the singleton created since the snapshot to be closed, got %v", lifecycleLog)
+		}
+	})
+
+	t.Run("Lifecycle Hooks", func(t *testing.T) {
+		ClearInstances()
+		starts := 0
+		OnStart(func(ctx context.Context) error {
+			starts++
+			return nil
+		})
+		snap := Snapshot()
+
+		if err := Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		Restore(snap)
+		if err := Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if starts != 2 {
+			t.Errorf("Expected the hook to be unstarted again after restore, got %d starts", starts)
+		}
+	})
+}
+
+type StrictRepo struct{ name string }
+
+func (r *StrictRepo) Name() string { return r.name }
+
+func NewStrictRepo() *StrictRepo {
+	return &StrictRepo{name: "repo"}
+}
+
+type StrictService struct{ repo *StrictRepo }
+
+func NewStrictService() *StrictService {
+	return &StrictService{repo: IOC(NewStrictRepo)}
+}
+
+type StrictNamer interface {
+	Name() string
+}
+
+type StrictConsumer struct{ namer StrictNamer }
+
+func NewStrictConsumer(namer StrictNamer) *StrictConsumer {
+	return &StrictConsumer{namer: namer}
+}
+
+type StrictLazyConsumer struct{ namer *Lazy[StrictNamer] }
+
+func NewStrictLazyConsumer(namer *Lazy[StrictNamer]) *StrictLazyConsumer {
+	return &StrictLazyConsumer{namer: namer}
+}
+
+// TestStrictMode tests rejecting unregistered and implicit resolutions
+func TestStrictMode(t *testing.T) {
+	recovered := func(fn func()) (message string) {
+		defer func() { message = fmt.Sprint(recover()) }()
+		fn()
+		return ""
+	}
+	strict := func(t *testing.T) {
+		ClearInstances()
+		Configure(WithStrictMode(true))
+		t.Cleanup(func() { Configure(WithStrictMode(false)) })
+	}
+
+	t.Run("Registered Providers", func(t *testing.T) {
+		strict(t)
+		Register(NewStrictService)
+
+		err := Validate(NewStrictService)
+		if err == nil || !strings.Contains(err.Error(), "strict mode: github.com/mstgnz/gioc.NewStrictRepo is not registered") {
+			t.Errorf("Expected the unregistered dependency to be rejected, got %v", err)
+		}
+
+		Register(NewStrictRepo)
+		if service := IOC(NewStrictService); service.repo.Name() != "repo" {
+			t.Error("Expected registered providers to resolve")
+		}
+		if message := recovered(func() { IOCFor(NewTenantDB, "a") }); !strings.Contains(message, "is not registered") {
+			t.Errorf("Expected IOCFor to require registration, got %q", message)
+		}
+		if _, err := IOCCtxErr(context.Background(), NewCtxDatabase); err == nil || !strings.Contains(err.Error(), "is not registered") {
+			t.Errorf("Expected IOCCtxErr to return the violation, got %v", err)
+		}
+	})
+
+	t.Run("Scoped Outside Scope", func(t *testing.T) {
+		strict(t)
+		Register(NewStrictRepo)
+
+		message := recovered(func() { IOC(NewStrictRepo, Scoped) })
+		if !strings.Contains(message, "NewStrictRepo is Scoped but no scope is active") {
+			t.Errorf("Expected a scoped resolution without scope to fail, got %q", message)
+		}
+		message = recovered(func() { DirectIOC(NewStrictRepo, Scoped) })
+		if !strings.Contains(message, "NewStrictRepo is Scoped but no scope is active") {
+			t.Errorf("Expected DirectIOC to fail the same way, got %q", message)
+		}
+
+		cleanup := BeginScope()
+		defer cleanup()
+		if IOC(NewStrictRepo, Scoped) != IOC(NewStrictRepo, Scoped) {
+			t.Error("Expected scoped resolution to work inside a scope")
+		}
+	})
+
+	t.Run("Type Key Collisions", func(t *testing.T) {
+		strict(t)
+		RegisterInstance(&StrictRepo{})
+
+		message := recovered(func() { RegisterInstance(&StrictRepo{}) })
+		if !strings.Contains(message, "RegisterInstance: an instance of *gioc.StrictRepo is already registered") {
+			t.Errorf("Expected a second registration to be rejected, got %q", message)
+		}
+
+		newFirst := func() any {
+			type Duplicate struct{ first bool }
+			return &Duplicate{}
+		}
+		newSecond := func() any {
+			type Duplicate struct{ second bool }
+			return &Duplicate{}
+		}
+		RegisterType(newFirst())
+		message = recovered(func() { RegisterType(newSecond()) })
+		if !strings.Contains(message, "RegisterType: type key *gioc.Duplicate is already used by") {
+			t.Errorf("Expected distinct types with the same key to be rejected, got %q", message)
+		}
+	})
+
+	t.Run("Assignable Parameters", func(t *testing.T) {
+		strict(t)
+		Register(NewStrictRepo)
+		IOC(NewStrictRepo)
+
+		message := recovered(func() { InjectConstructor[*StrictConsumer](NewStrictConsumer) })
+		if !strings.Contains(message, "of type gioc.StrictNamer") || !strings.Contains(message, "bind it with WithDependency") {
+			t.Errorf("Expected an assignable match to be rejected, got %q", message)
+		}
+
+		consumer := InjectConstructor[*StrictConsumer](NewStrictConsumer, WithDependency("namer", NewStrictRepo))
+		if consumer.namer.Name() != "repo" {
+			t.Error("Expected the bound dependency to be used")
+		}
+	})
+
+	t.Run("Assignable Handles", func(t *testing.T) {
+		strict(t)
+		Register(NewStrictRepo)
+		IOC(NewStrictRepo)
+
+		lazy := InjectConstructor[*StrictLazyConsumer](NewStrictLazyConsumer)
+		message := recovered(func() { lazy.namer.Get() })
+		if !strings.Contains(message, "dependency of type gioc.StrictNamer is only matched by an instance of *gioc.StrictRepo") {
+			t.Errorf("Expected an assignable instance to be rejected, got %q", message)
+		}
+
+		lazy = InjectConstructor[*StrictLazyConsumer](NewStrictLazyConsumer, WithDependency("other", NewStrictRepo))
+		message = recovered(func() { lazy.namer.Get() })
+		if !strings.Contains(message, "parameter namer of type gioc.StrictNamer is only matched by a dependency factory of *gioc.StrictRepo") {
+			t.Errorf("Expected an assignable dependency factory to be rejected, got %q", message)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		ClearInstances()
+		if IOC(NewStrictService).repo == nil {
+			t.Error("Expected unregistered providers to resolve without strict mode")
+		}
+	})
+}
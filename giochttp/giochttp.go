@@ -0,0 +1,299 @@
+// Package giochttp serves the state of the gioc container over HTTP for debugging,
+// similar to net/http/pprof.
+//
+// Example:
+//
+//	mux := http.NewServeMux()
+//	mux.Handle("/debug/gioc/", giochttp.DebugHandler())
+//	log.Fatal(http.ListenAndServe("localhost:6060", mux))
+//
+// The handler serves the following pages below the path it is mounted on:
+//
+//	/             HTML overview of providers, components, scopes and statistics
+//	/providers    JSON list of the known providers and whether they were resolved,
+//	              as returned by gioc.Providers
+//	/instances    JSON descriptors of every cached component, as returned by gioc.Describe
+//	/scopes       JSON description of the active scope
+//	/graph        JSON dependency graph with nodes and edges
+//	/graph.html   HTML page of the dependency graph, linking each component to its
+//	              dependencies and dependents
+//	/graph.dot    dependency graph in Graphviz DOT format
+//	/stats        JSON of gioc.MemoryStats
+//	/metrics      container metrics in Prometheus text format, see MetricsHandler
+package giochttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/mstgnz/gioc"
+)
+
+// Node is a component of the dependency graph
+type Node struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Scope string `json:"scope"`
+}
+
+// Edge is a dependency of From on To
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph is the dependency graph of the cached singletons
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// ScopeState describes the active scope
+type ScopeState struct {
+	// ID is the active scope, empty when no scope is active
+	ID        string            `json:"id"`
+	Instances []gioc.Descriptor `json:"instances"`
+}
+
+// DebugHandler returns a handler serving the container state. It is meant for
+// internal debugging endpoints and should not be exposed publicly.
+func DebugHandler() http.Handler {
+	return http.HandlerFunc(serveDebug)
+}
+
+// MetricsHandler returns a handler serving the container metrics in the Prometheus
+// text exposition format. Enable collection with gioc.WithMetrics.
+//
+// Example:
+//
+//	gioc.Configure(gioc.WithMetrics(true))
+//	http.Handle("/metrics", giochttp.MetricsHandler())
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := gioc.WriteMetrics(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// serveDebug dispatches on the last element of the request path
+func serveDebug(w http.ResponseWriter, r *http.Request) {
+	switch path.Base(r.URL.Path) {
+	case "providers":
+		writeJSON(w, gioc.Providers())
+	case "instances":
+		writeJSON(w, gioc.Describe())
+	case "scopes":
+		writeJSON(w, activeScope())
+	case "graph":
+		writeJSON(w, buildGraph())
+	case "graph.html":
+		serveGraph(w, buildGraph())
+	case "graph.dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		writeDOT(w, buildGraph())
+	case "stats":
+		writeJSON(w, gioc.MemoryStats())
+	case "metrics":
+		MetricsHandler().ServeHTTP(w, r)
+	default:
+		serveIndex(w, r)
+	}
+}
+
+// writeJSON encodes v as indented JSON
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// activeScope returns the active scope and its instances
+func activeScope() ScopeState {
+	state := ScopeState{ID: gioc.GetActiveScope(), Instances: []gioc.Descriptor{}}
+	for _, d := range gioc.Describe() {
+		if d.Kind == gioc.KindScoped {
+			state.Instances = append(state.Instances, d)
+		}
+	}
+	return state
+}
+
+// buildGraph collects the singletons and the dependencies recorded between them
+func buildGraph() Graph {
+	graph := Graph{Nodes: []Node{}, Edges: []Edge{}}
+	for _, d := range gioc.Describe() {
+		if d.Kind != gioc.KindSingleton {
+			continue
+		}
+		graph.Nodes = append(graph.Nodes, Node{ID: d.Key, Type: d.Type, Scope: d.Scope})
+		for _, dependency := range d.Dependencies {
+			graph.Edges = append(graph.Edges, Edge{From: d.Key, To: dependency})
+		}
+	}
+	return graph
+}
+
+// writeDOT writes graph in Graphviz DOT format
+func writeDOT(w http.ResponseWriter, graph Graph) {
+	fmt.Fprint(w, "digraph gioc {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(w, "\t%q [label=%q];\n", node.ID, node.ID+"\n"+node.Type)
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(w, "\t%q -> %q;\n", edge.From, edge.To)
+	}
+	fmt.Fprint(w, "}\n")
+}
+
+// graphNode is a node of the graph page with the edges leading in and out of it
+type graphNode struct {
+	Node
+	// Anchor identifies the node within the page
+	Anchor       string
+	Dependencies []*graphNode
+	Dependents   []*graphNode
+}
+
+// serveGraph renders graph as an HTML page
+func serveGraph(w http.ResponseWriter, graph Graph) {
+	nodes := make([]*graphNode, len(graph.Nodes))
+	byID := make(map[string]*graphNode, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		nodes[i] = &graphNode{Node: node, Anchor: fmt.Sprintf("node-%d", i)}
+		byID[node.ID] = nodes[i]
+	}
+	for _, edge := range graph.Edges {
+		from, to := byID[edge.From], byID[edge.To]
+		if from == nil || to == nil {
+			continue
+		}
+		from.Dependencies = append(from.Dependencies, to)
+		to.Dependents = append(to.Dependents, from)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := graphTemplate.Execute(w, nodes); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// indexData is rendered by indexTemplate
+type indexData struct {
+	Base        string
+	Providers   []gioc.ProviderDescriptor
+	Descriptors []gioc.Descriptor
+	Scope       string
+	Stats       map[string]int
+}
+
+// serveIndex renders the HTML overview
+func serveIndex(w http.ResponseWriter, r *http.Request) {
+	base := r.URL.Path
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+
+	data := indexData{
+		Base:        base,
+		Providers:   gioc.Providers(),
+		Descriptors: gioc.Describe(),
+		Scope:       gioc.GetActiveScope(),
+		Stats:       gioc.MemoryStats(),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := indexTemplate.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>gioc container</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+code { font-size: 90%; }
+</style>
+</head>
+<body>
+<h1>gioc container</h1>
+<p>
+<a href="{{.Base}}providers">providers</a> |
+<a href="{{.Base}}instances">instances</a> |
+<a href="{{.Base}}scopes">scopes</a> |
+<a href="{{.Base}}graph">graph</a> |
+<a href="{{.Base}}graph.html">graph.html</a> |
+<a href="{{.Base}}graph.dot">graph.dot</a> |
+<a href="{{.Base}}stats">stats</a> |
+<a href="{{.Base}}metrics">metrics</a>
+</p>
+<h2>Providers</h2>
+<table>
+<tr><th>Name</th><th>Type</th><th>Scope</th><th>Site</th><th>Registered</th><th>Resolved</th><th>Resolutions</th></tr>
+{{range .Providers}}<tr>
+<td><code>{{.Name}}</code></td><td><code>{{.Type}}</code></td><td>{{.Scope}}</td>
+<td><code>{{.Site}}</code></td><td>{{.Registered}}</td><td>{{.Resolved}}</td><td>{{.Resolutions}}</td>
+</tr>
+{{else}}<tr><td colspan="7">No providers</td></tr>
+{{end}}</table>
+<h2>Components</h2>
+<table>
+<tr><th>Kind</th><th>Key</th><th>Type</th><th>Scope</th><th>Source</th><th>Resolutions</th><th>Dependencies</th></tr>
+{{range .Descriptors}}<tr>
+<td>{{.Kind}}</td><td><code>{{.Key}}</code></td><td><code>{{.Type}}</code></td><td>{{.Scope}}</td>
+<td><code>{{.Source}}</code></td><td>{{.Resolutions}}</td>
+<td>{{range .Dependencies}}<code>{{.}}</code><br>{{end}}</td>
+</tr>
+{{else}}<tr><td colspan="7">No components</td></tr>
+{{end}}</table>
+<h2>Active scope</h2>
+<p>{{if .Scope}}<code>{{.Scope}}</code>{{else}}No active scope{{end}}</p>
+<h2>Memory statistics</h2>
+<table>
+{{range $name, $value := .Stats}}<tr><td>{{$name}}</td><td>{{$value}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+var graphTemplate = template.Must(template.New("graph").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>gioc dependency graph</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+code { font-size: 90%; }
+</style>
+</head>
+<body>
+<h1>gioc dependency graph</h1>
+<table>
+<tr><th>Component</th><th>Type</th><th>Scope</th><th>Depends on</th><th>Used by</th></tr>
+{{range .}}<tr id="{{.Anchor}}">
+<td><code>{{.ID}}</code></td><td><code>{{.Type}}</code></td><td>{{.Scope}}</td>
+<td>{{range .Dependencies}}<a href="#{{.Anchor}}"><code>{{.ID}}</code></a><br>{{end}}</td>
+<td>{{range .Dependents}}<a href="#{{.Anchor}}"><code>{{.ID}}</code></a><br>{{end}}</td>
+</tr>
+{{else}}<tr><td colspan="5">No components</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
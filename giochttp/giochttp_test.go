@@ -0,0 +1,149 @@
+package giochttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mstgnz/gioc"
+)
+
+type Repo struct{}
+
+func NewRepo() *Repo {
+	return &Repo{}
+}
+
+type Service struct {
+	repo *Repo
+}
+
+func NewService() *Service {
+	return &Service{repo: gioc.IOC(NewRepo)}
+}
+
+type Mailer struct{}
+
+func NewMailer() *Mailer {
+	return &Mailer{}
+}
+
+// get performs a request against the debug handler mounted under /debug/gioc/
+func get(t *testing.T, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle("/debug/gioc/", DebugHandler())
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET %s: expected 200, got %d", target, recorder.Code)
+	}
+	return recorder
+}
+
+// TestDebugHandler tests the pages served by the debug handler
+func TestDebugHandler(t *testing.T) {
+	gioc.ClearInstances()
+	gioc.Register(NewMailer)
+	gioc.IOC(NewService)
+
+	t.Run("Providers", func(t *testing.T) {
+		var providers []gioc.ProviderDescriptor
+		if err := json.Unmarshal(get(t, "/debug/gioc/providers").Body.Bytes(), &providers); err != nil {
+			t.Fatalf("Expected JSON providers: %v", err)
+		}
+		states := make(map[string]bool)
+		for _, provider := range providers {
+			states[provider.Name[strings.LastIndex(provider.Name, ".")+1:]] = provider.Resolved
+		}
+		if len(states) != 3 || !states["NewService"] || !states["NewRepo"] || states["NewMailer"] {
+			t.Errorf("Expected the registered mailer to be listed as unresolved, got %+v", providers)
+		}
+	})
+
+	t.Run("Instances", func(t *testing.T) {
+		var descriptors []gioc.Descriptor
+		if err := json.Unmarshal(get(t, "/debug/gioc/instances").Body.Bytes(), &descriptors); err != nil {
+			t.Fatalf("Expected JSON descriptors: %v", err)
+		}
+		if len(descriptors) != 2 {
+			t.Errorf("Expected 2 instances, got %d", len(descriptors))
+		}
+	})
+
+	t.Run("Graph", func(t *testing.T) {
+		var graph Graph
+		if err := json.Unmarshal(get(t, "/debug/gioc/graph").Body.Bytes(), &graph); err != nil {
+			t.Fatalf("Expected JSON graph: %v", err)
+		}
+		if len(graph.Nodes) != 2 || len(graph.Edges) != 1 || !strings.HasSuffix(graph.Edges[0].To, "NewRepo") {
+			t.Errorf("Unexpected graph: %+v", graph)
+		}
+
+		page := get(t, "/debug/gioc/graph.html").Body.String()
+		if !strings.Contains(page, "<h1>gioc dependency graph</h1>") || !strings.Contains(page, `<a href="#node-`) ||
+			!strings.Contains(page, "giochttp.NewRepo</code></a>") {
+			t.Errorf("Unexpected graph page: %s", page)
+		}
+
+		dot := get(t, "/debug/gioc/graph.dot").Body.String()
+		if !strings.HasPrefix(dot, "digraph gioc {") || !strings.Contains(dot, "->") {
+			t.Errorf("Unexpected DOT output: %s", dot)
+		}
+	})
+
+	t.Run("Scopes", func(t *testing.T) {
+		cleanup := gioc.BeginScope()
+		defer cleanup()
+		gioc.IOC(NewRepo, gioc.Scoped)
+
+		var scope ScopeState
+		if err := json.Unmarshal(get(t, "/debug/gioc/scopes").Body.Bytes(), &scope); err != nil {
+			t.Fatalf("Expected JSON scope: %v", err)
+		}
+		if scope.ID != gioc.GetActiveScope() || len(scope.Instances) != 1 {
+			t.Errorf("Unexpected scope state: %+v", scope)
+		}
+	})
+
+	t.Run("Stats And Index", func(t *testing.T) {
+		var stats map[string]int
+		if err := json.Unmarshal(get(t, "/debug/gioc/stats").Body.Bytes(), &stats); err != nil {
+			t.Fatalf("Expected JSON stats: %v", err)
+		}
+		if stats["instances"] != 2 {
+			t.Errorf("Expected 2 instances, got %d", stats["instances"])
+		}
+
+		index := get(t, "/debug/gioc/")
+		if !strings.Contains(index.Header().Get("Content-Type"), "text/html") ||
+			!strings.Contains(index.Body.String(), "NewService") ||
+			!strings.Contains(index.Body.String(), `href="/debug/gioc/graph.dot"`) {
+			t.Errorf("Unexpected index page: %s", index.Body.String())
+		}
+	})
+}
+
+// TestMetricsHandler tests the Prometheus text endpoint
+func TestMetricsHandler(t *testing.T) {
+	gioc.ClearInstances()
+	gioc.Configure(gioc.WithMetrics(true))
+	defer gioc.Configure(gioc.WithMetrics(false))
+
+	gioc.IOC(NewRepo)
+
+	recorder := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Unexpected content type %q", recorder.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(recorder.Body.String(), `gioc_constructions_total{provider="github.com/mstgnz/gioc/giochttp.NewRepo"} 1`) {
+		t.Errorf("Unexpected metrics output:\n%s", recorder.Body.String())
+	}
+	if !strings.Contains(get(t, "/debug/gioc/metrics").Body.String(), "gioc_resolutions_total") {
+		t.Error("Expected the debug handler to serve metrics")
+	}
+}
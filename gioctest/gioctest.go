@@ -0,0 +1,202 @@
+// Package gioctest hands the gioc container to tests one at a time.
+//
+// The gioc container is process-wide, so it cannot be isolated per test. Acquire
+// serializes access to it instead: the test holding it starts from an empty container
+// that is cleared again when the test ends, and other tests calling Acquire wait until
+// then. Tests may call t.Parallel before Acquire to run their other work concurrently,
+// but never after it: a paused test would keep the container while the sequential
+// tests it waits for block in Acquire.
+//
+// Example:
+//
+//	func TestHandler(t *testing.T) {
+//	    t.Parallel()
+//	    c := gioctest.Acquire(t)
+//
+//	    handler := gioc.IOC(NewUserHandler)
+//	    // exercise handler...
+//
+//	    c.AssertResolved(NewUserHandler, NewUserRepository)
+//	    c.AssertNotResolved(NewMailer)
+//	}
+//
+// When the test ends, Acquire reports scopes opened with gioc.BeginScope that were not
+// closed and singletons implementing gioc.Disposable or io.Closer that were not
+// released with gioc.Shutdown.
+package gioctest
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mstgnz/gioc"
+)
+
+var (
+	// exclusive is held by the test owning the container
+	exclusive sync.Mutex
+
+	// owner is the name of the test owning the container
+	owner      string
+	ownerMutex sync.Mutex
+)
+
+// Container is the gioc container handed to a test by Acquire
+type Container struct {
+	t testing.TB
+
+	// resolved counts the successful resolutions per provider
+	resolved      map[string]int
+	resolvedMutex sync.Mutex
+
+	// scopes is the number of open scopes when the test got the container
+	scopes int64
+}
+
+// Acquire waits until no other test holds the gioc container, clears it and hands it
+// to t. The container is checked for leaks, cleared and released again in t.Cleanup.
+// A test and its subtests share one container, so subtests must not call Acquire
+// again, and t.Parallel must not be called once the container is held.
+func Acquire(t testing.TB) *Container {
+	t.Helper()
+
+	ownerMutex.Lock()
+	holder := owner
+	ownerMutex.Unlock()
+	if holder != "" && strings.HasPrefix(t.Name(), holder+"/") {
+		t.Fatalf("gioctest: %s already owns the container; use it instead of calling Acquire again", holder)
+	}
+
+	exclusive.Lock()
+	ownerMutex.Lock()
+	owner = t.Name()
+	ownerMutex.Unlock()
+
+	gioc.ClearInstances()
+
+	c := &Container{
+		t:        t,
+		resolved: make(map[string]int),
+		scopes:   gioc.Metrics().ActiveScopes,
+	}
+	removeListener := gioc.AddListener(gioc.ListenerFunc(c.record))
+
+	t.Cleanup(func() {
+		defer exclusive.Unlock()
+		defer func() {
+			ownerMutex.Lock()
+			owner = ""
+			ownerMutex.Unlock()
+		}()
+
+		removeListener()
+		c.checkLeaks()
+		gioc.ClearInstances()
+	})
+	return c
+}
+
+// record counts the successful resolutions reported to listeners
+func (c *Container) record(event gioc.Event) {
+	if event.Kind != gioc.EventResolutionFinished || event.Err != nil {
+		return
+	}
+	c.resolvedMutex.Lock()
+	defer c.resolvedMutex.Unlock()
+	c.resolved[event.Name]++
+}
+
+// Resolved returns the providers resolved since Acquire, sorted by name. Providers are
+// named like gioc.Event.Name, which is the function name for factories.
+func (c *Container) Resolved() []string {
+	c.resolvedMutex.Lock()
+	defer c.resolvedMutex.Unlock()
+
+	names := make([]string, 0, len(c.resolved))
+	for name := range c.resolved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolutions returns how often provider was resolved since Acquire. provider is a
+// factory function or a provider name as returned by Resolved.
+func (c *Container) Resolutions(provider any) int {
+	c.resolvedMutex.Lock()
+	defer c.resolvedMutex.Unlock()
+	return c.resolved[providerName(provider)]
+}
+
+// AssertResolved fails the test unless every provider was resolved since Acquire
+func (c *Container) AssertResolved(providers ...any) {
+	c.t.Helper()
+	for _, provider := range providers {
+		if c.Resolutions(provider) == 0 {
+			c.t.Errorf("gioctest: expected %s to be resolved; resolved: %v", providerName(provider), c.Resolved())
+		}
+	}
+}
+
+// AssertNotResolved fails the test if any provider was resolved since Acquire
+func (c *Container) AssertNotResolved(providers ...any) {
+	c.t.Helper()
+	for _, provider := range providers {
+		if count := c.Resolutions(provider); count > 0 {
+			c.t.Errorf("gioctest: expected %s not to be resolved, resolved %d time(s)", providerName(provider), count)
+		}
+	}
+}
+
+// Override replaces real with fake for the rest of the test, see gioc.Override
+func Override[T any](t testing.TB, real, fake func() T) {
+	t.Helper()
+	t.Cleanup(gioc.Override(real, fake))
+}
+
+// OverrideInstance returns fake for every lookup of T for the rest of the test, see
+// gioc.OverrideInstance
+func OverrideInstance[T any](t testing.TB, fake T) {
+	t.Helper()
+	t.Cleanup(gioc.OverrideInstance(fake))
+}
+
+// checkLeaks fails the test for scopes left open and singletons left undisposed
+func (c *Container) checkLeaks() {
+	c.t.Helper()
+
+	if open := gioc.Metrics().ActiveScopes - c.scopes; open > 0 {
+		c.t.Errorf("gioctest: %d scope(s) opened with gioc.BeginScope were not closed", open)
+	}
+
+	for _, d := range gioc.Describe() {
+		if d.Kind != gioc.KindSingleton {
+			continue
+		}
+		switch d.Instance.(type) {
+		case gioc.Disposable, io.Closer:
+			c.t.Errorf("gioctest: %s (%s) was not released; call gioc.Shutdown before the test ends", d.Key, d.Type)
+		}
+	}
+}
+
+// providerName returns the name of a factory function, or provider itself when it
+// is a name
+func providerName(provider any) string {
+	if name, ok := provider.(string); ok {
+		return name
+	}
+	value := reflect.ValueOf(provider)
+	if value.Kind() == reflect.Func {
+		if f := runtime.FuncForPC(value.Pointer()); f != nil {
+			return f.Name()
+		}
+	}
+	return fmt.Sprintf("%v", provider)
+}
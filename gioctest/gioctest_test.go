@@ -0,0 +1,197 @@
+package gioctest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mstgnz/gioc"
+)
+
+type Repo struct {
+	name string
+}
+
+func NewRepo() *Repo {
+	return &Repo{}
+}
+
+type Service struct {
+	repo *Repo
+}
+
+func NewService() *Service {
+	return &Service{repo: gioc.IOC(NewRepo)}
+}
+
+type Mailer struct {
+	name string
+}
+
+func NewMailer() *Mailer {
+	return &Mailer{}
+}
+
+type Conn struct{}
+
+func (c *Conn) Close() error {
+	return nil
+}
+
+func NewConn() *Conn {
+	return &Conn{}
+}
+
+// recorder stands in for the test handed to Acquire, collecting its failures and cleanups
+type recorder struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recorder) Name() string {
+	return "recorder"
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Cleanup(fn func()) {
+	r.cleanups = append(r.cleanups, fn)
+}
+
+// finish runs the cleanups registered by Acquire
+func (r *recorder) finish() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+// TestIsolation tests that parallel tests get an empty container one at a time
+func TestIsolation(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		t.Run(fmt.Sprintf("Parallel %d", i), func(t *testing.T) {
+			t.Parallel()
+			c := Acquire(t)
+
+			if count := gioc.GetInstanceCount(); count != 0 {
+				t.Errorf("Expected an empty container, got %d instances", count)
+			}
+			gioc.IOC(NewService)
+			gioc.IOC(NewService)
+
+			if c.Resolutions(NewService) != 2 || c.Resolutions(NewRepo) != 1 {
+				t.Errorf("Unexpected resolutions: %d, %d", c.Resolutions(NewService), c.Resolutions(NewRepo))
+			}
+		})
+	}
+}
+
+// TestExclusive tests that Acquire waits until the container is released
+func TestExclusive(t *testing.T) {
+	first := &recorder{TB: t}
+	Acquire(first)
+	gioc.IOC(NewRepo)
+
+	acquired := make(chan int)
+	go func() {
+		second := &recorder{TB: t}
+		Acquire(second)
+		acquired <- gioc.GetInstanceCount()
+		second.finish()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Expected Acquire to wait while the container is held")
+	case <-time.After(20 * time.Millisecond):
+	}
+	first.finish()
+	if count := <-acquired; count != 0 {
+		t.Errorf("Expected the next test to get an empty container, got %d instances", count)
+	}
+}
+
+// TestAssertions tests the resolution assertions
+func TestAssertions(t *testing.T) {
+	c := Acquire(t)
+	gioc.IOC(NewService)
+
+	c.AssertResolved(NewService, NewRepo)
+	c.AssertNotResolved(NewMailer)
+
+	resolved := c.Resolved()
+	if len(resolved) != 2 || !strings.HasSuffix(resolved[0], "NewRepo") || !strings.HasSuffix(resolved[1], "NewService") {
+		t.Errorf("Unexpected resolved providers: %v", resolved)
+	}
+
+	r := &recorder{TB: t}
+	c.t = r
+	c.AssertResolved(NewMailer)
+	c.AssertNotResolved(NewRepo)
+	c.t = t
+	if len(r.errors) != 2 {
+		t.Errorf("Expected both assertions to fail, got %v", r.errors)
+	}
+}
+
+// TestOverride tests overrides scoped to the test
+func TestOverride(t *testing.T) {
+	c := Acquire(t)
+	fake := &Repo{name: "fake"}
+	OverrideInstance(t, fake)
+	fakeMailer := &Mailer{name: "fake"}
+	Override(t, NewMailer, func() *Mailer { return fakeMailer })
+
+	if service := gioc.IOC(NewService); service.repo != fake {
+		t.Error("Expected the service to get the fake repository")
+	}
+	if gioc.IOC(NewMailer) != fakeMailer {
+		t.Error("Expected the fake mailer factory to be used")
+	}
+	c.AssertResolved(NewMailer)
+}
+
+// TestLeaks tests the reports of unclosed scopes and undisposed singletons
+func TestLeaks(t *testing.T) {
+	t.Run("Scope", func(t *testing.T) {
+		r := &recorder{TB: t}
+		Acquire(r)
+		closeScope := gioc.BeginScope()
+		r.finish()
+		closeScope()
+
+		if len(r.errors) != 1 || !strings.Contains(r.errors[0], "1 scope(s) opened with gioc.BeginScope were not closed") {
+			t.Errorf("Expected a scope leak, got %v", r.errors)
+		}
+	})
+
+	t.Run("Disposable", func(t *testing.T) {
+		r := &recorder{TB: t}
+		Acquire(r)
+		gioc.IOC(NewConn)
+		r.finish()
+
+		if len(r.errors) != 1 || !strings.Contains(r.errors[0], "NewConn (*gioctest.Conn) was not released") {
+			t.Errorf("Expected a disposable leak, got %v", r.errors)
+		}
+	})
+
+	t.Run("Released", func(t *testing.T) {
+		r := &recorder{TB: t}
+		Acquire(r)
+		closeScope := gioc.BeginScope()
+		gioc.IOC(NewConn)
+		closeScope()
+		if err := gioc.Shutdown(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		r.finish()
+
+		if len(r.errors) != 0 {
+			t.Errorf("Expected no leaks, got %v", r.errors)
+		}
+	})
+}
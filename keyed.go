@@ -0,0 +1,466 @@
+package gioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// namedKey wraps a key passed to IOCKey so it cannot collide with internal keys
+type namedKey struct {
+	key any
+}
+
+var (
+	// keyIDs maps explicit keys to the synthetic identifiers used in the instance maps
+	keyIDs = make(map[any]uintptr)
+	// keyNames is the reverse of keyIDs, used when listing instances
+	keyNames = make(map[uintptr]any)
+	// scopedKeyRefs counts the scopes holding an IOCFor instance under each identifier,
+	// so the identifier is freed once the last of them releases it
+	scopedKeyRefs = make(map[uintptr]int)
+	// keyFactories records the factory that built the instance cached under an IOCKey
+	// identifier, so the injections registered for that factory can find it
+	keyFactories = make(map[uintptr]uintptr)
+	// nextKeyID counts down from the top of the address space so synthetic
+	// identifiers never collide with function entry points
+	nextKeyID = ^uintptr(0)
+	keyMutex  sync.RWMutex
+
+	// closureOwners remembers the first closure value seen for each code pointer
+	closureOwners = make(map[uintptr]unsafe.Pointer)
+	// closureWarned records code pointers that were already reported
+	closureWarned = make(map[uintptr]bool)
+	closureMutex  sync.Mutex
+)
+
+// keyLabel returns a readable name for an instance key: the factory name for code
+// pointers, the key for IOCKey and the factory name with its argument for IOCFor
+func keyLabel(key uintptr) string {
+	switch name := keyName(key).(type) {
+	case uintptr:
+		if f := runtime.FuncForPC(name); f != nil {
+			return f.Name()
+		}
+		return fmt.Sprintf("unknown(%d)", name)
+	case argKey:
+		return fmt.Sprintf("%s[%v]", keyLabel(name.fn), name.arg)
+	case asyncKey:
+		return fmt.Sprintf("async(%s)", keyLabel(name.fn))
+	default:
+		return fmt.Sprintf("key(%v)", name)
+	}
+}
+
+// providerLabel names the provider of key like keyLabel, except that the instances
+// built by IOCFor are named after their factory instead of their key
+func providerLabel(key uintptr) string {
+	if name, ok := keyName(key).(argKey); ok {
+		return keyLabel(name.fn)
+	}
+	return keyLabel(key)
+}
+
+// IOCKey works like IOC but caches the instance under the given key instead of
+// the factory's code pointer.
+//
+// IOC identifies factories by their entry point, so every closure created from the
+// same function literal shares one instance regardless of the values it captured.
+// IOCKey lets such closures be registered separately. The key must be comparable.
+//
+// Example:
+//
+//	for _, cfg := range configs {
+//	    cfg := cfg
+//	    client := gioc.IOCKey(cfg.Name, func() *Client { return NewClient(cfg) })
+//	    // Each configuration gets its own singleton client
+//	}
+func IOCKey[T any](key any, fn func() T, scope ...Scope) T {
+	// Initialize the instances map only once
+	once.Do(initializeContainer)
+
+	if key == nil || !reflect.TypeOf(key).Comparable() {
+		panic(fmt.Sprintf("IOCKey requires a comparable key, got %T", key))
+	}
+
+	id := keyID(namedKey{key: key})
+	fnPtr := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Entry()
+	if strictMode.Load() {
+		requireRegistered(fnPtr)
+	}
+
+	// Determine the scope (default to Singleton if not specified)
+	var componentScope Scope = Singleton
+	if len(scope) > 0 {
+		componentScope = scope[0]
+	}
+
+	// Check for dependency cycles
+	if !fastPath.Load() {
+		registerProvider(id, reflect.TypeOf((*T)(nil)).Elem(), componentScope, 1)
+		panicOnCycle(id)
+	}
+
+	instance := resolve(id, componentScope, func() any {
+		bindKeyFactory(id, fnPtr)
+		return fn()
+	})
+	if typed, ok := instance.(T); ok {
+		return typed
+	}
+	panic(fmt.Sprintf("type assertion failed in keyed %s instance: expected %T, got %T for key %v", componentScope, *new(T), instance, key))
+}
+
+// keyID returns the synthetic identifier for key, allocating one on first use
+func keyID(key any) uintptr {
+	keyMutex.RLock()
+	id, exists := keyIDs[key]
+	keyMutex.RUnlock()
+	if exists {
+		return id
+	}
+
+	keyMutex.Lock()
+	defer keyMutex.Unlock()
+
+	if id, exists := keyIDs[key]; exists {
+		return id
+	}
+	id = nextKeyID
+	nextKeyID--
+	keyIDs[key] = id
+	keyNames[id] = key
+	return id
+}
+
+// bindKeyFactory records fnPtr as the factory building the instance cached under id
+func bindKeyFactory(id, fnPtr uintptr) {
+	keyMutex.Lock()
+	keyFactories[id] = fnPtr
+	keyMutex.Unlock()
+}
+
+// factoryEntry returns the entry point of the factory that builds the instances cached
+// under key: the factory passed to IOCKey or IOCFor for synthetic identifiers, and the
+// key itself otherwise
+func factoryEntry(key uintptr) uintptr {
+	keyMutex.RLock()
+	defer keyMutex.RUnlock()
+
+	if fnPtr, exists := keyFactories[key]; exists {
+		return fnPtr
+	}
+	if name, ok := keyNames[key].(argKey); ok {
+		return name.fn
+	}
+	return key
+}
+
+// keyName returns the key registered for a synthetic identifier, or the identifier itself
+func keyName(id uintptr) any {
+	keyMutex.RLock()
+	defer keyMutex.RUnlock()
+
+	if key, exists := keyNames[id]; exists {
+		if named, ok := key.(namedKey); ok {
+			return named.key
+		}
+		return key
+	}
+	return id
+}
+
+// WithClosureDetection enables or disables closure collision detection.
+//
+// When enabled, IOC and DirectIOC log a warning the first time a factory's code
+// pointer is resolved with a closure capturing a different value than the one seen
+// first. Such closures share a single cached instance, which usually means IOCKey
+// should be used instead. Closures are compared by the first value they capture, such
+// as the receiver of a method value, so a closure created again over the same value
+// is not reported.
+func WithClosureDetection(enabled bool) Option {
+	return func(c *containerConfig) {
+		c.closureDetection = enabled
+	}
+}
+
+// checkClosure reports a warning when fnPtr was seen with a different closure value
+func checkClosure(fnPtr uintptr, closure unsafe.Pointer) {
+	closureMutex.Lock()
+	defer closureMutex.Unlock()
+
+	first, exists := closureOwners[fnPtr]
+	if !exists {
+		closureOwners[fnPtr] = closure
+		return
+	}
+	if sameCapture(first, closure) || closureWarned[fnPtr] {
+		return
+	}
+
+	closureWarned[fnPtr] = true
+	warnf("factory %s was resolved with different closure values that share one cached instance; use IOCKey to cache them separately",
+		runtime.FuncForPC(fnPtr).Name())
+}
+
+// sameCapture reports whether two closures of one function literal or method value
+// capture the same first value. A closure stores its captured values after the code
+// pointer, and one capturing nothing is never allocated twice.
+func sameCapture(a, b unsafe.Pointer) bool {
+	if a == b {
+		return true
+	}
+	word := unsafe.Sizeof(uintptr(0))
+	return *(*uintptr)(unsafe.Add(a, word)) == *(*uintptr)(unsafe.Add(b, word))
+}
+
+// closureOf returns the closure value behind a function variable
+func closureOf(fnRef unsafe.Pointer) unsafe.Pointer {
+	return *(*unsafe.Pointer)(fnRef)
+}
+
+// argKey identifies the instance built by a factory for a single argument value
+type argKey struct {
+	fn  uintptr
+	arg any
+}
+
+// IOCFor resolves the instance built by fn for the given key, caching one instance
+// per key within the chosen scope (Singleton by default). This is useful for
+// components such as per-tenant connections that are built from a runtime value.
+//
+// Cached instances can be listed with KeysFor and removed with EvictFor. The
+// bookkeeping kept for a key is freed when its singleton is evicted or when the last
+// scope holding its Scoped instance ends; Transient resolutions are tracked under fn.
+//
+// Example:
+//
+//	func NewTenantDB(tenantID string) *TenantDB {
+//	    return &TenantDB{dsn: "postgres://db/" + tenantID}
+//	}
+//
+//	func handle(tenantID string) {
+//	    db := gioc.IOCFor(NewTenantDB, tenantID)
+//	    // Same *TenantDB for every call with this tenant ID
+//	}
+func IOCFor[K comparable, T any](fn func(K) T, key K, scope ...Scope) T {
+	// Initialize the instances map only once
+	once.Do(initializeContainer)
+
+	fnPtr := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Entry()
+	requireRegistered(fnPtr)
+
+	// Determine the scope (default to Singleton if not specified)
+	var componentScope Scope = Singleton
+	if len(scope) > 0 {
+		componentScope = scope[0]
+	}
+
+	// Transient instances are not cached, so they are tracked under the factory instead
+	// of an identifier per key that nothing would free
+	id := fnPtr
+	if componentScope != Transient {
+		id = keyID(argKey{fn: fnPtr, arg: key})
+	}
+
+	// Check for dependency cycles
+	if !fastPath.Load() {
+		registerProvider(id, reflect.TypeOf((*T)(nil)).Elem(), componentScope, 1)
+		panicOnCycle(id)
+	}
+
+	instance := resolve(id, componentScope, func() any { return fn(key) })
+	if typed, ok := instance.(T); ok {
+		return typed
+	}
+	funcName := runtime.FuncForPC(fnPtr).Name()
+	panic(fmt.Sprintf("type assertion failed in keyed %s instance: expected %T, got %T for function %s and key %v", componentScope, *new(T), instance, funcName, key))
+}
+
+// KeysFor returns the keys that currently have a cached instance built by fn.
+// Singleton instances are listed by default; pass Scoped to list the keys cached in
+// the active scope. The order of the returned keys is unspecified.
+func KeysFor[K comparable, T any](fn func(K) T, scope ...Scope) []K {
+	// Initialize the instances map only once
+	once.Do(initializeContainer)
+
+	fnPtr := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Entry()
+
+	var componentScope Scope = Singleton
+	if len(scope) > 0 {
+		componentScope = scope[0]
+	}
+
+	// Collect the identifiers allocated for this factory first
+	keyMutex.RLock()
+	candidates := make(map[uintptr]K)
+	for id, name := range keyNames {
+		if k, ok := name.(argKey); ok && k.fn == fnPtr {
+			candidates[id] = k.arg.(K)
+		}
+	}
+	keyMutex.RUnlock()
+
+	keys := make([]K, 0, len(candidates))
+	switch componentScope {
+	case Singleton:
+		mu.RLock()
+		for id, key := range candidates {
+			if _, exists := instances[id]; exists {
+				keys = append(keys, key)
+			}
+		}
+		mu.RUnlock()
+	case Scoped:
+		if scopeCtx := getCurrentScopeContext(); scopeCtx != nil {
+			for id, key := range candidates {
+				if _, exists := scopeCtx.Get(id); exists {
+					keys = append(keys, key)
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// EvictFor removes the instance cached by fn for the given key and disposes it.
+// Singleton instances are evicted by default, together with the bookkeeping kept for
+// the key, after their started stop hooks have run; pass Scoped to evict from the
+// active scope. It returns the errors reported while stopping and disposing the
+// instance, if any.
+//
+// Example:
+//
+//	func onTenantDeleted(tenantID string) {
+//	    if err := gioc.EvictFor(NewTenantDB, tenantID); err != nil {
+//	        log.Printf("closing tenant db: %v", err)
+//	    }
+//	}
+func EvictFor[K comparable, T any](fn func(K) T, key K, scope ...Scope) error {
+	// Initialize the instances map only once
+	once.Do(initializeContainer)
+
+	fnPtr := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Entry()
+
+	keyMutex.RLock()
+	id, exists := keyIDs[argKey{fn: fnPtr, arg: key}]
+	keyMutex.RUnlock()
+	if !exists {
+		return nil
+	}
+
+	var componentScope Scope = Singleton
+	if len(scope) > 0 {
+		componentScope = scope[0]
+	}
+
+	var instance any
+	var stopErr error
+	switch componentScope {
+	case Singleton:
+		mu.Lock()
+		instance, exists = instances[id]
+		delete(instances, id)
+		delete(types, id)
+		delete(scopes, id)
+		delete(dependencyGraph, id)
+		delete(instanceInfos, id)
+		mu.Unlock()
+		// The finalizer would otherwise remove a later instance stored under the same key
+		if exists {
+			runtime.SetFinalizer(instance, nil)
+		}
+		forgetKey(argKey{fn: fnPtr, arg: key}, id)
+
+		hooks := dropLifecycleHooks(func(hookKey uintptr) bool { return hookKey == id })
+		if len(hooks) > 0 {
+			lifecycleRunMutex.Lock()
+			stopErr = stopHooks(context.Background(), hooks)
+			lifecycleRunMutex.Unlock()
+		}
+	case Scoped:
+		scopeCtx := getCurrentScopeContext()
+		if scopeCtx == nil {
+			return nil
+		}
+		instance, exists = scopeCtx.Get(id)
+		scopeCtx.Delete(id)
+	default:
+		return nil
+	}
+
+	if !exists {
+		return stopErr
+	}
+	return errors.Join(stopErr, disposeInstance(id, instance))
+}
+
+// retainScopedKey records that a scope holds the IOCFor instance cached under id
+func retainScopedKey(id uintptr) {
+	keyMutex.RLock()
+	_, keyed := keyNames[id].(argKey)
+	keyMutex.RUnlock()
+	if !keyed {
+		return
+	}
+
+	keyMutex.Lock()
+	scopedKeyRefs[id]++
+	keyMutex.Unlock()
+}
+
+// releaseScopedKey records that a scope dropped the instance cached under id, and
+// forgets the identifier once no scope or singleton uses it any more
+func releaseScopedKey(id uintptr) {
+	keyMutex.RLock()
+	_, held := scopedKeyRefs[id]
+	keyMutex.RUnlock()
+	if !held {
+		return
+	}
+
+	keyMutex.Lock()
+	refs, held := scopedKeyRefs[id]
+	if !held || refs > 1 {
+		if held {
+			scopedKeyRefs[id] = refs - 1
+		}
+		keyMutex.Unlock()
+		return
+	}
+	delete(scopedKeyRefs, id)
+	key := keyNames[id]
+	keyMutex.Unlock()
+
+	// A singleton cached for the same key keeps using the identifier
+	mu.RLock()
+	_, cached := instances[id]
+	mu.RUnlock()
+	if !cached {
+		forgetKey(key, id)
+	}
+}
+
+// forgetKey drops the synthetic identifier of key and everything recorded under it, so
+// evicted keys do not accumulate. A later resolution of key gets a new identifier.
+func forgetKey(key any, id uintptr) {
+	keyMutex.Lock()
+	delete(keyIDs, key)
+	delete(keyNames, id)
+	delete(keyFactories, id)
+	keyMutex.Unlock()
+
+	resolutionCounts.Delete(id)
+
+	providersMutex.Lock()
+	delete(providers, id)
+	providersMutex.Unlock()
+
+	factoryTimingsMutex.Lock()
+	delete(factoryTimings, id)
+	factoryTimingsMutex.Unlock()
+}
@@ -0,0 +1,250 @@
+package gioc
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// injectableHandle is implemented by *Lazy[T] and *Provider[T] so the container can
+// create them for constructor parameters and tagged fields, deferring the actual
+// resolution of T until Get is called
+type injectableHandle interface {
+	// bind sets the function used to resolve the target type
+	bind(resolve func() any)
+	// targetType returns the type the handle resolves
+	targetType() reflect.Type
+}
+
+// handleType is the reflect type of injectableHandle
+var handleType = reflect.TypeOf((*injectableHandle)(nil)).Elem()
+
+// Lazy defers resolving a dependency until Get is first called and returns the
+// same value afterwards. Cycle detection happens when Get resolves the dependency.
+//
+// A *Lazy[T] parameter of a constructor passed to InjectConstructor or Factory, or a
+// field tagged with `gioc:"inject"`, is injected automatically. It builds T with the
+// factory declared for it with Register, or else finds a live instance of T.
+//
+// Example:
+//
+//	type ReportService struct {
+//	    db *gioc.Lazy[*Database]
+//	}
+//
+//	func NewReportService(db *gioc.Lazy[*Database]) *ReportService {
+//	    return &ReportService{db: db}
+//	}
+//
+//	func (s *ReportService) Run() {
+//	    s.db.Get().Query("...") // Database is resolved here
+//	}
+type Lazy[T any] struct {
+	mu       sync.Mutex
+	resolved atomic.Bool
+	resolve  func() T
+	value    T
+}
+
+// NewLazy returns a Lazy that resolves fn with IOC in the given scope on first use
+func NewLazy[T any](fn func() T, scope ...Scope) *Lazy[T] {
+	return &Lazy[T]{resolve: func() T { return IOC(fn, scope...) }}
+}
+
+// LazyOf returns a factory for a Lazy resolving fn, for use with TypedInjectConstructor,
+// CreateFactory and WithDependency
+func LazyOf[T any](fn func() T, scope ...Scope) func() *Lazy[T] {
+	return func() *Lazy[T] { return NewLazy(fn, scope...) }
+}
+
+// Get resolves the dependency on the first call and returns the cached value afterwards.
+// A resolution that panics caches nothing, so the next Get tries again.
+func (l *Lazy[T]) Get() T {
+	if l.resolved.Load() {
+		return l.value
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.resolved.Load() {
+		if l.resolve == nil {
+			panic(fmt.Sprintf("lazy %v has no resolver; create it with NewLazy or let the container inject it", l.targetType()))
+		}
+		l.value = l.resolve()
+		l.resolved.Store(true)
+	}
+	return l.value
+}
+
+func (l *Lazy[T]) bind(resolve func() any) {
+	l.resolve = func() T { return resolve().(T) }
+}
+
+func (l *Lazy[T]) targetType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Provider resolves a dependency every time Get is called, honoring the scope the
+// dependency was requested with: singletons are shared, scoped instances follow the
+// active scope and transients are created on each call.
+//
+// A *Provider[T] parameter of a constructor passed to InjectConstructor or Factory, or
+// a field tagged with `gioc:"inject"`, is injected automatically. It builds T with the
+// factory declared for it with Register, in the registered scope, or else finds a live
+// instance of T.
+//
+// Example:
+//
+//	type Worker struct {
+//	    jobs *gioc.Provider[*Job]
+//	}
+//
+//	func NewWorker() *Worker {
+//	    return &Worker{jobs: gioc.NewProvider(NewJob, gioc.Transient)}
+//	}
+//
+//	func (w *Worker) Handle() {
+//	    job := w.jobs.Get() // A fresh Job for every call
+//	}
+type Provider[T any] struct {
+	resolve func() T
+}
+
+// NewProvider returns a Provider that resolves fn with IOC in the given scope on every call
+func NewProvider[T any](fn func() T, scope ...Scope) *Provider[T] {
+	return &Provider[T]{resolve: func() T { return IOC(fn, scope...) }}
+}
+
+// ProviderOf returns a factory for a Provider resolving fn, for use with
+// TypedInjectConstructor, CreateFactory and WithDependency
+func ProviderOf[T any](fn func() T, scope ...Scope) func() *Provider[T] {
+	return func() *Provider[T] { return NewProvider(fn, scope...) }
+}
+
+// Get resolves the dependency
+func (p *Provider[T]) Get() T {
+	if p.resolve == nil {
+		panic(fmt.Sprintf("provider %v has no resolver; create it with NewProvider or let the container inject it", p.targetType()))
+	}
+	return p.resolve()
+}
+
+func (p *Provider[T]) bind(resolve func() any) {
+	p.resolve = func() T { return resolve().(T) }
+}
+
+func (p *Provider[T]) targetType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// isHandleType reports whether t is a *Lazy or *Provider type
+func isHandleType(t reflect.Type) bool {
+	return t != nil && t.Implements(handleType)
+}
+
+// newHandle creates a handle of type t that calls resolve on Get
+func newHandle(t reflect.Type, resolve func() any) reflect.Value {
+	value := reflect.New(t.Elem())
+	value.Interface().(injectableHandle).bind(resolve)
+	return value
+}
+
+// targetOf returns the type resolved by handle type t
+func targetOf(t reflect.Type) reflect.Type {
+	return reflect.Zero(t).Interface().(injectableHandle).targetType()
+}
+
+// resolveTyped resolves a TypedInjectConstructor dependency. Handle factories such as
+// LazyOf and ProviderOf are called directly, because their closures share one code
+// pointer and would otherwise be cached as a single instance by IOC.
+func resolveTyped[D any](dep func() D) D {
+	if isHandleType(reflect.TypeOf((*D)(nil)).Elem()) {
+		return dep()
+	}
+	return IOC(dep)
+}
+
+// buildByType resolves t with the only factory registered with Register that takes no
+// arguments and returns t, in its registered scope, or else finds it with resolveByType
+func buildByType(t reflect.Type) (any, bool) {
+	if fake, ok := instanceOverride(t); ok {
+		return fake, true
+	}
+
+	var (
+		factory        reflect.Value
+		componentScope Scope
+		matches        int
+	)
+	providersMutex.RLock()
+	for _, info := range providers {
+		if info.registered && info.result == t && isFactory(info.factory) {
+			factory, componentScope = info.factory, info.scope
+			matches++
+		}
+	}
+	providersMutex.RUnlock()
+
+	if matches == 1 {
+		return resolveFactoryIn(factory, componentScope), true
+	}
+	return resolveByType(t)
+}
+
+// resolveByType finds a live instance assignable to t. An instance set for t with
+// OverrideInstance wins; otherwise the active scope is searched first, then
+// singletons, then instances added with RegisterInstance and RegisterType. Strict
+// mode rejects instances that are only assignable to t.
+func resolveByType(t reflect.Type) (any, bool) {
+	if fake, ok := instanceOverride(t); ok {
+		return fake, true
+	}
+
+	if scopeCtx := getCurrentScopeContext(); scopeCtx != nil {
+		scopeCtx.mu.RLock()
+		instance, found := findAssignable(scopeCtx.instances, t)
+		scopeCtx.mu.RUnlock()
+		if found {
+			requireExactMatch("dependency", t, "an instance", reflect.TypeOf(instance))
+			return instance, true
+		}
+	}
+
+	mu.RLock()
+	instance, found := findAssignable(instances, t)
+	mu.RUnlock()
+	if found {
+		requireExactMatch("dependency", t, "an instance", reflect.TypeOf(instance))
+		return instance, true
+	}
+
+	typeRegistryMutex.RLock()
+	instance, found = typeRegistry[t.String()]
+	typeRegistryMutex.RUnlock()
+	if found {
+		return instance, true
+	}
+
+	directMutex.RLock()
+	instance, found = directInstances[t.String()]
+	directMutex.RUnlock()
+	return instance, found
+}
+
+// findAssignable returns an instance of exactly type t, or else one assignable to t
+func findAssignable(candidates map[uintptr]any, t reflect.Type) (any, bool) {
+	var assignable any
+	found := false
+	for _, instance := range candidates {
+		instType := reflect.TypeOf(instance)
+		if instType == t {
+			return instance, true
+		}
+		if !found && instType != nil && instType.AssignableTo(t) {
+			assignable = instance
+			found = true
+		}
+	}
+	return assignable, found
+}
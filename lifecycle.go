@@ -0,0 +1,348 @@
+package gioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Starter is implemented by components that need to start background work after the
+// dependency graph has been built. Start is called by gioc.Start.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by components that need to stop background work. Stop is
+// called by gioc.Stop.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// lifecycleHook is a start and/or stop function in dependency order. key is the
+// singleton the hook belongs to, or zero for hooks registered outside any factory.
+type lifecycleHook struct {
+	key     uintptr
+	name    string
+	start   func(ctx context.Context) error
+	stop    func(ctx context.Context) error
+	started bool
+}
+
+var (
+	// lifecycleHooks holds hooks in the order components were created or hooks were
+	// registered. Singletons are stored after their dependencies, so this order starts
+	// dependencies before their dependents.
+	lifecycleHooks []*lifecycleHook
+	lifecycleMutex sync.Mutex
+	// lifecycleRunMutex serializes Start and Stop
+	lifecycleRunMutex sync.Mutex
+
+	// creationOrder lists singleton keys in the order they were stored, guarded by mu
+	creationOrder []uintptr
+
+	// ignoredHooks records the hooks of non-singleton factories that were already reported
+	ignoredHooks = sync.Map{} // map[hook name]bool
+)
+
+// OnStart registers a hook that gioc.Start runs after the hooks of the components
+// created before this call. Call it from a factory to tie the hook to the component:
+// the hook is dropped when the singleton is removed by Shutdown, EvictFor or Restore.
+// Factories resolved as Transient or Scoped, and constructors called by
+// InjectConstructor or Factory, build a new component every time, so hooks they
+// register are ignored with a warning instead of piling up. Hooks registered outside
+// any factory belong to the container and are only dropped by ClearInstances.
+//
+// Example:
+//
+//	func NewWorker() *Worker {
+//	    w := &Worker{queue: gioc.IOC(NewQueue)}
+//	    gioc.OnStart(w.run)
+//	    gioc.OnStop(w.shutdown)
+//	    return w
+//	}
+func OnStart(fn func(ctx context.Context) error) {
+	addFactoryHook(&lifecycleHook{name: funcName(fn), start: fn})
+}
+
+// OnStop registers a hook that gioc.Stop runs before the hooks of the components
+// created before this call. Like OnStart, it ties the hook to the singleton being built
+// and ignores hooks of other factories.
+func OnStop(fn func(ctx context.Context) error) {
+	addFactoryHook(&lifecycleHook{name: funcName(fn), stop: fn})
+}
+
+// WithHookTimeout limits how long each start or stop hook may run. A hook exceeding
+// the timeout fails with context.DeadlineExceeded even if it ignores its context.
+// Zero, the default, applies no limit beyond the context passed to Start or Stop.
+func WithHookTimeout(timeout time.Duration) Option {
+	return func(c *containerConfig) {
+		c.hookTimeout = timeout
+	}
+}
+
+// Start runs the start hooks of singletons implementing Starter and of hooks registered
+// with OnStart, in dependency order. Hooks that already ran are skipped, so Start can
+// be called again after new components were created.
+//
+// If a hook fails, the components started by this call are stopped in reverse order
+// and the error is returned together with any rollback errors. A successful Start ends
+// the startup window recorded by StartupReport.
+//
+// Example:
+//
+//	server := gioc.IOC(NewServer)
+//	if err := gioc.Start(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer gioc.Stop(context.Background())
+func Start(ctx context.Context) error {
+	lifecycleRunMutex.Lock()
+	defer lifecycleRunMutex.Unlock()
+
+	lifecycleMutex.Lock()
+	hooks := append([]*lifecycleHook(nil), lifecycleHooks...)
+	lifecycleMutex.Unlock()
+
+	var startedNow []*lifecycleHook
+	for _, hook := range hooks {
+		if hook.started {
+			continue
+		}
+		if hook.start != nil {
+			if err := runHook(ctx, hook.start); err != nil {
+				startErr := fmt.Errorf("start %s: %w", hook.name, err)
+				return errors.Join(startErr, stopHooks(ctx, startedNow))
+			}
+		}
+		hook.started = true
+		startedNow = append(startedNow, hook)
+	}
+	startupDone.Store(true)
+	return nil
+}
+
+// Stop runs the stop hooks of started components in reverse dependency order. All
+// hooks are run even if some fail; the failures are returned joined together.
+func Stop(ctx context.Context) error {
+	lifecycleRunMutex.Lock()
+	defer lifecycleRunMutex.Unlock()
+
+	lifecycleMutex.Lock()
+	hooks := append([]*lifecycleHook(nil), lifecycleHooks...)
+	lifecycleMutex.Unlock()
+
+	return stopHooks(ctx, hooks)
+}
+
+// stopHooks runs the stop hooks of the started entries of hooks in reverse order
+func stopHooks(ctx context.Context, hooks []*lifecycleHook) error {
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if !hook.started {
+			continue
+		}
+		hook.started = false
+		if hook.stop == nil {
+			continue
+		}
+		if err := runHook(ctx, hook.stop); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", hook.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runHook runs fn, enforcing the configured hook timeout and giving up when ctx is done
+// even if fn ignores it. A panicking hook fails with the panic as its error.
+func runHook(ctx context.Context, fn func(ctx context.Context) error) error {
+	configMutex.RLock()
+	timeout := config.hookTimeout
+	configMutex.RUnlock()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return runWithin(ctx, func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("hook panicked: %v", r)
+			}
+		}()
+		return fn(ctx)
+	})
+}
+
+// runWithin calls fn on its own goroutine and returns the context error if ctx is done
+// first, leaving fn running. fn is called directly when ctx can never be done.
+func runWithin(ctx context.Context, fn func() error) error {
+	if ctx.Done() == nil {
+		return fn()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// fn may have finished at the same time
+		select {
+		case err := <-done:
+			return err
+		default:
+			return ctx.Err()
+		}
+	}
+}
+
+// addFactoryHook ties hook to the singleton whose factory is the innermost one running
+// on the current goroutine, and ignores it when that factory builds a new component on
+// every call
+func addFactoryHook(hook *lifecycleHook) {
+	if frame, ok := currentFactory(); ok {
+		if frame.scope != Singleton {
+			if _, warned := ignoredHooks.LoadOrStore(hook.name, true); !warned {
+				warnf("lifecycle hook %s registered by a %s factory is ignored", hook.name, frame.scope)
+			}
+			return
+		}
+		hook.key = frame.key
+	}
+	addLifecycleHook(hook)
+}
+
+// addLifecycleHook appends a hook in dependency order
+func addLifecycleHook(hook *lifecycleHook) {
+	lifecycleMutex.Lock()
+	defer lifecycleMutex.Unlock()
+	lifecycleHooks = append(lifecycleHooks, hook)
+}
+
+// dropLifecycleHooks removes the hooks of the singletons for which removed returns true
+// and returns them. Hooks registered outside any factory are kept.
+func dropLifecycleHooks(removed func(key uintptr) bool) []*lifecycleHook {
+	lifecycleMutex.Lock()
+	defer lifecycleMutex.Unlock()
+
+	var dropped []*lifecycleHook
+	kept := lifecycleHooks[:0]
+	for _, hook := range lifecycleHooks {
+		if hook.key != 0 && removed(hook.key) {
+			dropped = append(dropped, hook)
+			continue
+		}
+		kept = append(kept, hook)
+	}
+	clear(lifecycleHooks[len(kept):])
+	lifecycleHooks = kept
+	return dropped
+}
+
+// registerLifecycle records the Starter and Stopper hooks of a newly stored singleton
+func registerLifecycle(key uintptr, instance any) {
+	starter, isStarter := instance.(Starter)
+	stopper, isStopper := instance.(Stopper)
+	if !isStarter && !isStopper {
+		return
+	}
+
+	hook := &lifecycleHook{key: key, name: fmt.Sprintf("%v (%T)", keyName(key), instance)}
+	if name := runtime.FuncForPC(key); name != nil {
+		hook.name = fmt.Sprintf("%s (%T)", name.Name(), instance)
+	}
+	if isStarter {
+		hook.start = starter.Start
+	}
+	if isStopper {
+		hook.stop = stopper.Stop
+	}
+	addLifecycleHook(hook)
+}
+
+// funcName returns the name of the function fn
+func funcName(fn any) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return fmt.Sprintf("%T", fn)
+}
+
+// Shutdown stops the started components with Stop, then disposes every singleton
+// implementing Disposable or io.Closer in reverse creation order and removes the
+// singletons and their lifecycle hooks from the container. Disposal stops early when ctx is done, even when a
+// hook, Dispose or Close ignores it; the remaining singletons are reported in the
+// returned error.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := gioc.Shutdown(ctx); err != nil {
+//	    log.Printf("shutdown: %v", err)
+//	}
+func Shutdown(ctx context.Context) error {
+	errs := []error{Stop(ctx)}
+
+	mu.Lock()
+	order := creationOrder
+	live := make(map[uintptr]any, len(order))
+	for _, key := range order {
+		if instance, exists := instances[key]; exists {
+			live[key] = instance
+			delete(instances, key)
+			delete(types, key)
+			delete(scopes, key)
+			delete(instanceInfos, key)
+		}
+	}
+	creationOrder = nil
+	mu.Unlock()
+
+	// Singletons built again later register new hooks, so the old ones must not restart
+	dropLifecycleHooks(func(key uintptr) bool {
+		_, removed := live[key]
+		return removed
+	})
+
+	// Dispose dependents before their dependencies
+	for i := len(order) - 1; i >= 0; i-- {
+		key := order[i]
+		instance, exists := live[key]
+		if !exists {
+			continue
+		}
+		delete(live, key)
+		runtime.SetFinalizer(instance, nil)
+
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("dispose %v: %w", keyName(key), err))
+			continue
+		}
+		if err := disposeWithin(ctx, key, instance); err != nil {
+			errs = append(errs, fmt.Errorf("dispose %T: %w", instance, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// disposeWithin disposes instance like disposeInstance, giving up when ctx is done
+func disposeWithin(ctx context.Context, key uintptr, instance any) error {
+	switch instance.(type) {
+	case Disposable, io.Closer:
+		return runWithin(ctx, func() error { return disposeInstance(key, instance) })
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,157 @@
+package gioc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"reflect"
+	"sync/atomic"
+)
+
+// containerLogger mirrors containerConfig.logger for the event path
+var containerLogger atomic.Pointer[slog.Logger]
+
+// WithLogger sends container diagnostics to logger as structured records: resolutions
+// and scope changes at debug level, slow factories and warnings at warn level, and
+// cycles, failed resolutions and disposal errors at error level. Passing nil restores
+// the default of printing warnings with the standard log package.
+//
+// Resolution events are only built when the logger is enabled at debug level or a
+// listener is registered, so a logger at a higher level only costs a check per
+// resolution and still records failures.
+//
+// Example:
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+//	gioc.Configure(gioc.WithLogger(logger))
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *containerConfig) {
+		c.logger = logger
+	}
+}
+
+// logEvent writes event to logger at the level matching its severity
+func logEvent(logger *slog.Logger, event Event) {
+	ctx := context.Background()
+
+	switch event.Kind {
+	case EventResolutionFinished:
+		if event.Err != nil {
+			logger.LogAttrs(ctx, slog.LevelError, "gioc resolution failed",
+				slog.String("provider", event.Name),
+				slog.String("scope", event.Scope.String()),
+				slog.Any("error", event.Err))
+			return
+		}
+		if logger.Enabled(ctx, slog.LevelDebug) {
+			logger.LogAttrs(ctx, slog.LevelDebug, "gioc resolved",
+				slog.String("provider", event.Name),
+				slog.String("type", typeName(event.Type)),
+				slog.String("scope", event.Scope.String()),
+				slog.Duration("duration", event.Duration),
+				slog.Bool("cache_hit", event.CacheHit))
+		}
+	case EventSlowFactory:
+		logger.LogAttrs(ctx, slog.LevelWarn, "gioc slow factory",
+			slog.String("provider", event.Name),
+			slog.String("type", typeName(event.Type)),
+			slog.String("scope", event.Scope.String()),
+			slog.Duration("duration", event.Duration))
+	case EventCycleDetected:
+		logger.LogAttrs(ctx, slog.LevelError, "gioc circular dependency",
+			slog.String("provider", event.Name),
+			slog.String("path", event.Path))
+	case EventInstanceDisposed:
+		if event.Err != nil {
+			logger.LogAttrs(ctx, slog.LevelError, "gioc disposal failed",
+				slog.String("provider", event.Name),
+				slog.String("type", typeName(event.Type)),
+				slog.Any("error", event.Err))
+			return
+		}
+		logger.LogAttrs(ctx, slog.LevelDebug, "gioc disposed",
+			slog.String("provider", event.Name),
+			slog.String("type", typeName(event.Type)))
+	case EventScopeOpened:
+		logger.LogAttrs(ctx, slog.LevelDebug, "gioc scope opened",
+			slog.String("scope_id", string(event.ScopeID)))
+	case EventScopeClosed:
+		logger.LogAttrs(ctx, slog.LevelDebug, "gioc scope closed",
+			slog.String("scope_id", string(event.ScopeID)))
+	case EventProviderRegistered:
+		logger.LogAttrs(ctx, slog.LevelDebug, "gioc registered",
+			slog.String("provider", event.Name),
+			slog.String("type", typeName(event.Type)))
+	}
+}
+
+// warnf reports a non-fatal container diagnostic through the configured logger
+func warnf(format string, args ...any) {
+	if logger := containerLogger.Load(); logger != nil {
+		logger.Warn("gioc: " + fmt.Sprintf(format, args...))
+		return
+	}
+	log.Printf("gioc: warning: "+format, args...)
+}
+
+// typeName returns the name of t, or an empty string when t is unknown
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
+
+// LogInstances writes one record per registered instance to logger, the structured
+// counterpart of ListInstances.
+//
+// Example:
+//
+//	gioc.LogInstances(slog.Default())
+func LogInstances(logger *slog.Logger) {
+	for _, d := range describeKind(KindSingleton) {
+		logger.Info("gioc instance",
+			slog.String("key", d.Key),
+			slog.String("type", d.Type),
+			slog.String("scope", d.Scope))
+	}
+}
+
+// LogScopedInstances writes one record per instance in the current scope to logger,
+// the structured counterpart of ListScopedInstances.
+func LogScopedInstances(logger *slog.Logger) {
+	scopeCtx := getCurrentScopeContext()
+	if scopeCtx == nil {
+		logger.Info("gioc no active scope")
+		return
+	}
+
+	for _, d := range describeKind(KindScoped) {
+		logger.Info("gioc scoped instance",
+			slog.String("scope_id", string(scopeCtx.id)),
+			slog.String("key", d.Key),
+			slog.String("type", d.Type))
+	}
+}
+
+// LogDependencyStatus writes the dependency resolution state to logger, the structured
+// counterpart of ListDependencyStatus.
+func LogDependencyStatus(logger *slog.Logger) {
+	var pathCount int
+	resolutionPathMap.Range(func(_, _ interface{}) bool {
+		pathCount++
+		return true
+	})
+
+	singletons := describeKind(KindSingleton)
+	logger.Info("gioc dependency status",
+		slog.Int("active_resolution_goroutines", pathCount),
+		slog.Int("registered_types", len(singletons)))
+	for _, d := range singletons {
+		logger.Info("gioc registered type",
+			slog.String("key", d.Key),
+			slog.String("type", d.Type),
+			slog.Any("dependencies", d.Dependencies))
+	}
+}
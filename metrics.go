@@ -0,0 +1,245 @@
+package gioc
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the construction latency histogram
+var latencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// providerCounters holds the metrics of one provider
+type providerCounters struct {
+	resolutions   atomic.Int64
+	cacheHits     atomic.Int64
+	constructions atomic.Int64
+	failures      atomic.Int64
+	// buckets counts constructions per latency bucket, the last one being +Inf
+	buckets    [10]atomic.Int64
+	latencySum atomic.Int64
+}
+
+// ProviderMetrics is a snapshot of the metrics of one provider
+type ProviderMetrics struct {
+	// Provider is the factory name or key, as in Event.Provider. The instances built
+	// by IOCFor are counted under their factory, keeping the number of providers bounded.
+	Provider      string `json:"provider"`
+	Resolutions   int64  `json:"resolutions"`
+	CacheHits     int64  `json:"cacheHits"`
+	Constructions int64  `json:"constructions"`
+	Failures      int64  `json:"failures"`
+	// LatencyBuckets counts constructions taking at most the matching LatencyBounds
+	// entry, cumulatively like a Prometheus histogram; the last entry counts all
+	LatencyBuckets []int64 `json:"latencyBuckets"`
+	// LatencySum is the total time spent in successful constructions
+	LatencySum time.Duration `json:"latencySum"`
+}
+
+// ContainerMetrics is a snapshot of the container metrics
+type ContainerMetrics struct {
+	Providers []ProviderMetrics `json:"providers"`
+	// LatencyBounds are the upper bounds of the latency buckets, without +Inf
+	LatencyBounds  []time.Duration `json:"latencyBounds"`
+	ActiveScopes   int64           `json:"activeScopes"`
+	DisposalErrors int64           `json:"disposalErrors"`
+}
+
+var (
+	// providerMetrics holds the counters per provider name
+	providerMetrics = sync.Map{} // map[string]*providerCounters
+	// disposalErrors counts failed disposals while metrics are enabled
+	disposalErrors atomic.Int64
+	// activeScopeCount is the number of scopes opened by BeginScope and not yet closed
+	activeScopeCount atomic.Int64
+
+	// removeMetricsListener unregisters the metrics listener, nil when disabled
+	removeMetricsListener func()
+	metricsMutex          sync.Mutex
+	expvarOnce            sync.Once
+)
+
+// WithMetrics enables or disables the collection of per-provider resolution counters,
+// construction latency histograms and disposal errors. Collection uses the event
+// listener mechanism, so it adds no cost while disabled. The active scope gauge is
+// always maintained.
+//
+// Example:
+//
+//	gioc.Configure(gioc.WithMetrics(true))
+//	gioc.PublishExpvar()
+//	http.Handle("/metrics", giochttp.MetricsHandler())
+func WithMetrics(enabled bool) Option {
+	return func(c *containerConfig) {
+		c.metrics = enabled
+	}
+}
+
+// setMetricsListener registers or removes the listener collecting metrics
+func setMetricsListener(enabled bool) {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+
+	if enabled && removeMetricsListener == nil {
+		removeMetricsListener = AddListener(ListenerFunc(recordMetrics))
+	}
+	if !enabled && removeMetricsListener != nil {
+		removeMetricsListener()
+		removeMetricsListener = nil
+	}
+}
+
+// recordMetrics updates the counters from a container event
+func recordMetrics(event Event) {
+	switch event.Kind {
+	case EventResolutionFinished:
+		counters := countersFor(event.Provider)
+		counters.resolutions.Add(1)
+		switch {
+		case event.Err != nil:
+			counters.failures.Add(1)
+		case event.CacheHit:
+			counters.cacheHits.Add(1)
+		default:
+			counters.constructions.Add(1)
+			counters.latencySum.Add(int64(event.Duration))
+			bucket := sort.Search(len(latencyBuckets), func(i int) bool {
+				return event.Duration <= latencyBuckets[i]
+			})
+			counters.buckets[bucket].Add(1)
+		}
+	case EventInstanceDisposed:
+		if event.Err != nil {
+			disposalErrors.Add(1)
+		}
+	}
+}
+
+// countersFor returns the counters of provider, creating them on first use
+func countersFor(provider string) *providerCounters {
+	if counters, ok := providerMetrics.Load(provider); ok {
+		return counters.(*providerCounters)
+	}
+	counters, _ := providerMetrics.LoadOrStore(provider, new(providerCounters))
+	return counters.(*providerCounters)
+}
+
+// resetMetrics clears the provider counters and disposal errors
+func resetMetrics() {
+	providerMetrics.Clear()
+	disposalErrors.Store(0)
+}
+
+// Metrics returns a snapshot of the container metrics, with providers sorted by name
+func Metrics() ContainerMetrics {
+	snapshot := ContainerMetrics{
+		Providers:      []ProviderMetrics{},
+		LatencyBounds:  slices.Clone(latencyBuckets),
+		ActiveScopes:   activeScopeCount.Load(),
+		DisposalErrors: disposalErrors.Load(),
+	}
+
+	providerMetrics.Range(func(name, value any) bool {
+		counters := value.(*providerCounters)
+		provider := ProviderMetrics{
+			Provider:       name.(string),
+			Resolutions:    counters.resolutions.Load(),
+			CacheHits:      counters.cacheHits.Load(),
+			Constructions:  counters.constructions.Load(),
+			Failures:       counters.failures.Load(),
+			LatencyBuckets: make([]int64, len(counters.buckets)),
+			LatencySum:     time.Duration(counters.latencySum.Load()),
+		}
+		var cumulative int64
+		for i := range counters.buckets {
+			cumulative += counters.buckets[i].Load()
+			provider.LatencyBuckets[i] = cumulative
+		}
+		snapshot.Providers = append(snapshot.Providers, provider)
+		return true
+	})
+
+	sort.Slice(snapshot.Providers, func(i, j int) bool {
+		return snapshot.Providers[i].Provider < snapshot.Providers[j].Provider
+	})
+	return snapshot
+}
+
+// PublishExpvar publishes the container metrics as the expvar variable "gioc", served
+// by the expvar handler at /debug/vars. Calling it again has no effect.
+func PublishExpvar() {
+	expvarOnce.Do(func() {
+		expvar.Publish("gioc", expvar.Func(func() any { return Metrics() }))
+	})
+}
+
+// WriteMetrics writes the container metrics to w in the Prometheus text exposition
+// format.
+//
+// Example:
+//
+//	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+//	    gioc.WriteMetrics(w)
+//	})
+func WriteMetrics(w io.Writer) error {
+	snapshot := Metrics()
+	var b strings.Builder
+
+	counters := []struct {
+		name, help string
+		value      func(ProviderMetrics) int64
+	}{
+		{"gioc_resolutions_total", "Resolutions per provider.", func(p ProviderMetrics) int64 { return p.Resolutions }},
+		{"gioc_cache_hits_total", "Resolutions served from the cache per provider.", func(p ProviderMetrics) int64 { return p.CacheHits }},
+		{"gioc_constructions_total", "Instances built per provider.", func(p ProviderMetrics) int64 { return p.Constructions }},
+		{"gioc_construction_failures_total", "Failed resolutions per provider.", func(p ProviderMetrics) int64 { return p.Failures }},
+	}
+	for _, counter := range counters {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", counter.name, counter.help, counter.name)
+		for _, provider := range snapshot.Providers {
+			fmt.Fprintf(&b, "%s{provider=\"%s\"} %d\n", counter.name, escapeLabel(provider.Provider), counter.value(provider))
+		}
+	}
+
+	const histogram = "gioc_construction_duration_seconds"
+	fmt.Fprintf(&b, "# HELP %s Time spent building instances per provider.\n# TYPE %s histogram\n", histogram, histogram)
+	for _, provider := range snapshot.Providers {
+		label := escapeLabel(provider.Provider)
+		for i, bound := range snapshot.LatencyBounds {
+			fmt.Fprintf(&b, "%s_bucket{provider=\"%s\",le=\"%s\"} %d\n", histogram, label,
+				strconv.FormatFloat(bound.Seconds(), 'g', -1, 64), provider.LatencyBuckets[i])
+		}
+		fmt.Fprintf(&b, "%s_bucket{provider=\"%s\",le=\"+Inf\"} %d\n", histogram, label, provider.Constructions)
+		fmt.Fprintf(&b, "%s_sum{provider=\"%s\"} %s\n", histogram, label,
+			strconv.FormatFloat(provider.LatencySum.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(&b, "%s_count{provider=\"%s\"} %d\n", histogram, label, provider.Constructions)
+	}
+
+	fmt.Fprintf(&b, "# HELP gioc_active_scopes Scopes opened by BeginScope and not yet closed.\n# TYPE gioc_active_scopes gauge\ngioc_active_scopes %d\n", snapshot.ActiveScopes)
+	fmt.Fprintf(&b, "# HELP gioc_disposal_errors_total Failed disposals of instances.\n# TYPE gioc_disposal_errors_total counter\ngioc_disposal_errors_total %d\n", snapshot.DisposalErrors)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeLabel escapes a Prometheus label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,169 @@
+package gioc
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Option configures container-wide behaviour.
+// Options are applied with Configure.
+type Option func(*containerConfig)
+
+// containerConfig holds the container-wide settings changed through Configure
+type containerConfig struct {
+	// production enables the reduced bookkeeping resolution mode once Validate succeeds
+	production bool
+	// closureDetection reports closures sharing a code pointer with different captures
+	closureDetection bool
+	// hookTimeout limits each lifecycle hook, zero means no limit
+	hookTimeout time.Duration
+	// warmupWorkers limits the factories Warmup runs concurrently
+	warmupWorkers int
+	// providerTimeouts limits the construction time of context-aware factories
+	providerTimeouts map[uintptr]time.Duration
+	// slowFactoryThreshold is the self time above which factories are reported, zero
+	// means the default and a negative value disables the detection
+	slowFactoryThreshold time.Duration
+	// profiling wraps factory calls in trace regions and pprof labels
+	profiling bool
+	// logger receives structured diagnostics, nil means warnings go to the log package
+	logger *slog.Logger
+	// strictMode only accepts registered providers and rejects implicit matches
+	strictMode bool
+	// metrics collects per-provider counters through an event listener
+	metrics bool
+}
+
+var (
+	// config is the active container configuration
+	config      containerConfig
+	configMutex sync.RWMutex
+
+	// validated is set when the last call to Validate succeeded
+	validated atomic.Bool
+	// fastPath is set when production mode is enabled and the graph has been validated.
+	// It is read on every resolution, so it is kept outside of configMutex.
+	fastPath atomic.Bool
+	// closureDetection mirrors containerConfig.closureDetection for the hot path
+	closureDetection atomic.Bool
+)
+
+// Configure applies the given options to the container.
+// It can be called at any time; options only affect resolutions that start afterwards.
+// The options are applied together: if one of them panics, none takes effect.
+//
+// Example:
+//
+//	func main() {
+//	    gioc.Configure(gioc.WithProductionMode(true))
+//	    if err := gioc.Validate(NewUserHandler); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    handler := gioc.IOC(NewUserHandler)
+//	}
+func Configure(opts ...Option) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	// Apply the options to a copy, so a panicking option leaves the configuration alone
+	next := config
+	next.providerTimeouts = maps.Clone(config.providerTimeouts)
+	for _, opt := range opts {
+		opt(&next)
+	}
+	config = next
+	syncConfig(config)
+}
+
+// syncConfig mirrors the settings of c read outside of configMutex, such as the atomics
+// checked on every resolution. configMutex must be held.
+func syncConfig(c containerConfig) {
+	fastPath.Store(c.production && validated.Load())
+	closureDetection.Store(c.closureDetection)
+	slowFactoryWatch.Store(c.slowFactoryThreshold > 0)
+	profiling.Store(c.profiling)
+	containerLogger.Store(c.logger)
+	strictMode.Store(c.strictMode)
+	setMetricsListener(c.metrics)
+}
+
+// WithProductionMode enables or disables production resolution mode.
+//
+// Production mode only takes effect after a successful call to Validate. From then on
+// cached singletons and scoped instances are returned without cycle tracking, the
+// type map used for diagnostics is no longer filled and InjectConstructor only parses
+// parameter names when named dependencies are supplied. Cache misses are still
+// resolved with full cycle tracking, so unexpected cycles keep panicking with the
+// resolution path instead of overflowing the stack.
+func WithProductionMode(enabled bool) Option {
+	return func(c *containerConfig) {
+		c.production = enabled
+	}
+}
+
+// Validate resolves the given root factories as singletons with full cycle tracking
+// and reports any panic raised while building them as an error.
+// Each root must be a function taking no arguments and returning exactly one value.
+//
+// A successful Validate switches the container to the production fast path if
+// production mode is enabled. ClearInstances resets the validation state.
+//
+// Example:
+//
+//	if err := gioc.Validate(NewUserHandler, NewAdminHandler); err != nil {
+//	    log.Fatalf("invalid dependency graph: %v", err)
+//	}
+func Validate(roots ...interface{}) error {
+	once.Do(initializeContainer)
+
+	// Validation always runs with full tracking
+	fastPath.Store(false)
+
+	var errs []error
+	for _, root := range roots {
+		if err := validateRoot(root); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err := errors.Join(errs...)
+	validated.Store(err == nil)
+
+	configMutex.RLock()
+	fastPath.Store(config.production && err == nil)
+	configMutex.RUnlock()
+
+	return err
+}
+
+// validateRoot resolves a single root factory, converting panics to errors
+func validateRoot(root interface{}) (err error) {
+	rootValue := reflect.ValueOf(root)
+	if !isFactory(rootValue) {
+		return fmt.Errorf("validate: root %T must be a function with no arguments returning one value", root)
+	}
+
+	fnPtr := runtime.FuncForPC(rootValue.Pointer()).Entry()
+	funcName := runtime.FuncForPC(fnPtr).Name()
+
+	// Keep the caller's resolution path so a recovered panic does not leave stale entries behind
+	currentPath := getCurrentResolutionPath()
+	defer func() {
+		if r := recover(); r != nil {
+			updateResolutionPath(currentPath)
+			err = fmt.Errorf("validate %s: %v", funcName, r)
+		}
+	}()
+
+	panicOnCycle(fnPtr)
+
+	resolveFactory(rootValue)
+	return nil
+}
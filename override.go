@@ -0,0 +1,279 @@
+package gioc
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// cachedInstance is a singleton removed from the container while an override is active
+type cachedInstance struct {
+	instance any
+	typ      reflect.Type
+	scope    Scope
+	info     instanceInfo
+}
+
+var (
+	// factoryOverrides maps the key of a replaced factory to its fake factory
+	factoryOverrides = make(map[uintptr]any)
+	// instanceOverrides maps a type to the fake instance returned for it
+	instanceOverrides = make(map[reflect.Type]any)
+	// overrideGeneration changes when ClearInstances drops all overrides, so that
+	// undoing an override made before has no effect
+	overrideGeneration uint64
+	overrideMutex      sync.RWMutex
+
+	// overrideCount is the number of active overrides, checked before any lookup
+	overrideCount atomic.Int64
+)
+
+// Override makes the container call fake wherever real would be called, until the
+// returned function is called. It affects IOC, DirectIOC and the factories passed to
+// InjectConstructor with WithDependency. The instance cached for real and the
+// singletons depending on it are set aside while the override is active and put back
+// when it is undone. Override panics once the container is sealed by Validate in
+// production mode.
+//
+// Example:
+//
+//	func TestCheckout(t *testing.T) {
+//	    t.Cleanup(gioc.Override(NewPaymentGateway, NewFakePaymentGateway))
+//
+//	    checkout := gioc.IOC(NewCheckout)
+//	    // checkout uses the fake gateway
+//	}
+func Override[T any](real, fake func() T) func() {
+	once.Do(initializeContainer)
+	checkOverridable("Override")
+
+	key := runtime.FuncForPC(reflect.ValueOf(real).Pointer()).Entry()
+
+	overrideMutex.Lock()
+	previous, existed := factoryOverrides[key]
+	factoryOverrides[key] = fake
+	generation := overrideGeneration
+	overrideCount.Store(int64(len(factoryOverrides) + len(instanceOverrides)))
+	overrideMutex.Unlock()
+
+	saved := evictDependents([]uintptr{key})
+
+	return undoOverride(generation, saved, func() []uintptr {
+		if existed {
+			factoryOverrides[key] = previous
+		} else {
+			delete(factoryOverrides, key)
+		}
+		return []uintptr{key}
+	})
+}
+
+// OverrideInstance makes every lookup of type T return fake until the returned
+// function is called: IOC and DirectIOC of factories returning T, constructor
+// parameters of type T, GetInstance and GetType. Singletons of type T and the
+// singletons depending on them are set aside while the override is active and put
+// back when it is undone. OverrideInstance panics once the container is sealed by
+// Validate in production mode.
+//
+// Example:
+//
+//	func TestSignup(t *testing.T) {
+//	    mailer := &FakeMailer{}
+//	    t.Cleanup(gioc.OverrideInstance[Mailer](mailer))
+//
+//	    gioc.IOC(NewSignupService).Register("ada@example.com")
+//	    // mailer received the welcome mail
+//	}
+func OverrideInstance[T any](fake T) func() {
+	once.Do(initializeContainer)
+	checkOverridable("OverrideInstance")
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	overrideMutex.Lock()
+	previous, existed := instanceOverrides[typ]
+	instanceOverrides[typ] = fake
+	generation := overrideGeneration
+	overrideCount.Store(int64(len(factoryOverrides) + len(instanceOverrides)))
+	overrideMutex.Unlock()
+
+	saved := evictDependents(keysOfType(typ))
+
+	return undoOverride(generation, saved, func() []uintptr {
+		if existed {
+			instanceOverrides[typ] = previous
+		} else {
+			delete(instanceOverrides, typ)
+		}
+		return keysOfType(typ)
+	})
+}
+
+// undoOverride returns the function undoing an override made in generation. revert
+// restores the previous override with overrideMutex held and returns the keys built
+// with the fake, which are removed before the singletons in saved are put back.
+func undoOverride(generation uint64, saved map[uintptr]cachedInstance, revert func() []uintptr) func() {
+	var undone atomic.Bool
+	return func() {
+		if !undone.CompareAndSwap(false, true) {
+			return
+		}
+
+		overrideMutex.Lock()
+		if generation != overrideGeneration {
+			// ClearInstances already dropped the override and the saved singletons
+			overrideMutex.Unlock()
+			return
+		}
+		keys := revert()
+		overrideCount.Store(int64(len(factoryOverrides) + len(instanceOverrides)))
+		overrideMutex.Unlock()
+
+		evictDependents(keys)
+		restoreInstances(saved)
+	}
+}
+
+// checkOverridable panics when the container is sealed in production mode
+func checkOverridable(name string) {
+	if fastPath.Load() {
+		panic(name + " is not allowed once the container is sealed in production mode")
+	}
+}
+
+// overriddenFactory returns the fake factory replacing the factory of key, or fn
+func overriddenFactory[T any](key uintptr, fn func() T) func() T {
+	overrideMutex.RLock()
+	defer overrideMutex.RUnlock()
+	if fake, exists := factoryOverrides[key].(func() T); exists {
+		return fake
+	}
+	return fn
+}
+
+// overriddenFactoryValue returns the fake factory replacing factory, or factory
+func overriddenFactoryValue(factory reflect.Value) reflect.Value {
+	if overrideCount.Load() == 0 || factory.Kind() != reflect.Func {
+		return factory
+	}
+	key := runtime.FuncForPC(factory.Pointer()).Entry()
+
+	overrideMutex.RLock()
+	defer overrideMutex.RUnlock()
+	if fake, exists := factoryOverrides[key]; exists {
+		return reflect.ValueOf(fake)
+	}
+	return factory
+}
+
+// overriddenInstance returns the fake instance set for T with OverrideInstance
+func overriddenInstance[T any]() (T, bool) {
+	if overrideCount.Load() == 0 {
+		var zero T
+		return zero, false
+	}
+	instance, exists := instanceOverride(reflect.TypeOf((*T)(nil)).Elem())
+	if !exists {
+		var zero T
+		return zero, false
+	}
+	typed, ok := instance.(T)
+	return typed, ok
+}
+
+// instanceOverride returns the fake instance set for t with OverrideInstance
+func instanceOverride(t reflect.Type) (any, bool) {
+	if overrideCount.Load() == 0 {
+		return nil, false
+	}
+	overrideMutex.RLock()
+	defer overrideMutex.RUnlock()
+	instance, exists := instanceOverrides[t]
+	return instance, exists
+}
+
+// keysOfType returns the keys of the singletons and providers of type t
+func keysOfType(t reflect.Type) []uintptr {
+	var keys []uintptr
+	mu.RLock()
+	for key, typ := range types {
+		if typ == t {
+			keys = append(keys, key)
+		}
+	}
+	mu.RUnlock()
+
+	providersMutex.RLock()
+	for key, info := range providers {
+		if info.result == t {
+			keys = append(keys, key)
+		}
+	}
+	providersMutex.RUnlock()
+	return keys
+}
+
+// evictDependents removes the singletons of keys and of everything depending on them
+// from the container, along with their instances in the active scope, and returns
+// the removed singletons
+func evictDependents(keys []uintptr) map[uintptr]cachedInstance {
+	evicted := make(map[uintptr]cachedInstance)
+
+	mu.Lock()
+	dependents := make(map[uintptr][]uintptr)
+	for parent, children := range dependencyGraph {
+		for child := range children {
+			dependents[child] = append(dependents[child], parent)
+		}
+	}
+
+	visited := make(map[uintptr]bool)
+	for len(keys) > 0 {
+		key := keys[len(keys)-1]
+		keys = keys[:len(keys)-1]
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+		keys = append(keys, dependents[key]...)
+
+		if instance, exists := instances[key]; exists {
+			evicted[key] = cachedInstance{instance: instance, typ: types[key], scope: scopes[key], info: instanceInfos[key]}
+			delete(instances, key)
+			delete(types, key)
+			delete(scopes, key)
+			delete(instanceInfos, key)
+		}
+	}
+	mu.Unlock()
+
+	if scopeCtx := getCurrentScopeContext(); scopeCtx != nil {
+		for key := range visited {
+			scopeCtx.Delete(key)
+		}
+	}
+	return evicted
+}
+
+// restoreInstances puts singletons removed by evictDependents back into the container
+func restoreInstances(saved map[uintptr]cachedInstance) {
+	mu.Lock()
+	defer mu.Unlock()
+	for key, cached := range saved {
+		instances[key] = cached.instance
+		types[key] = cached.typ
+		scopes[key] = cached.scope
+		instanceInfos[key] = cached.info
+	}
+}
+
+// resetOverrides drops all overrides
+func resetOverrides() {
+	overrideMutex.Lock()
+	defer overrideMutex.Unlock()
+	factoryOverrides = make(map[uintptr]any)
+	instanceOverrides = make(map[reflect.Type]any)
+	overrideGeneration++
+	overrideCount.Store(0)
+}
@@ -0,0 +1,55 @@
+package gioc
+
+import (
+	"runtime/pprof"
+	"runtime/trace"
+	"sync/atomic"
+)
+
+// profiling mirrors containerConfig.profiling for the factory path
+var profiling atomic.Bool
+
+// WithProfiling enables or disables profiling annotations around factory calls.
+//
+// When enabled, every factory runs inside a runtime/trace region named after the
+// provider and under the pprof labels "gioc.provider" and "gioc.scope", so go tool
+// trace and CPU or goroutine profiles attribute work to the component being built.
+// Nested factories replace the labels of their dependents while they run. The
+// labeled context is available to the factory through ResolutionContext.
+//
+// The gioc labels are added to the labels of the resolution context, and the goroutine
+// gets the labels of that context back once the factory returns. Goroutine labels can
+// only be read from a context, so resolve with IOCCtx and the context passed to
+// pprof.Do to keep labels the caller set on its goroutine.
+//
+// Example:
+//
+//	gioc.Configure(gioc.WithProfiling(true))
+//	pprof.StartCPUProfile(f)
+//	app := gioc.IOC(NewApplication)
+//	pprof.StopCPUProfile()
+func WithProfiling(enabled bool) Option {
+	return func(c *containerConfig) {
+		c.profiling = enabled
+	}
+}
+
+// profileFactory calls the factory of key inside a trace region and under pprof labels
+func profileFactory[T any](key uintptr, componentScope Scope, call func() T) T {
+	name := keyLabel(key)
+	labels := pprof.Labels("gioc.provider", name, "gioc.scope", componentScope.String())
+
+	// Start from the labels of the caller's context and give them back afterwards
+	parent := ResolutionContext()
+	ctx := pprof.WithLabels(parent, labels)
+	pprof.SetGoroutineLabels(ctx)
+	defer pprof.SetGoroutineLabels(parent)
+
+	restore := enterResolutionContext(ctx)
+	defer restore()
+
+	region := trace.StartRegion(ctx, name)
+	defer region.End()
+
+	return call()
+}
@@ -0,0 +1,262 @@
+package gioc
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// providerInfo describes a provider the container has seen
+type providerInfo struct {
+	// result is the declared result type of the factory
+	result reflect.Type
+	// scope is the scope the provider was first resolved or registered with
+	scope Scope
+	// site is the file:line where the provider was first resolved or registered
+	site string
+	// registered is set when the provider was registered with Register
+	registered bool
+	// factory is the function passed to Register
+	factory reflect.Value
+	// resolved is set once the provider has been resolved
+	resolved bool
+}
+
+var (
+	// providers holds the providers seen so far, keyed like instances
+	providers      = make(map[uintptr]*providerInfo)
+	providersMutex sync.RWMutex
+)
+
+// registerProvider records that the provider of key is being resolved, along with its
+// result type, scope and call site the first time it is seen. skip is the number of
+// stack frames above the caller of registerProvider to attribute the call site to; a
+// negative skip records no site.
+func registerProvider(key uintptr, result reflect.Type, componentScope Scope, skip int) {
+	providersMutex.RLock()
+	info, known := providers[key]
+	resolved := known && info.resolved
+	providersMutex.RUnlock()
+	if resolved {
+		return
+	}
+
+	// A registered provider keeps the site of its registration
+	site := ""
+	if _, file, line, ok := runtime.Caller(skip + 1); !known && skip >= 0 && ok {
+		site = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+	if info, known = providers[key]; !known {
+		info = &providerInfo{result: result, scope: componentScope, site: site}
+		providers[key] = info
+	}
+	info.resolved = true
+}
+
+// providerOf returns what is known about the provider of key
+func providerOf(key uintptr) (providerInfo, bool) {
+	providersMutex.RLock()
+	defer providersMutex.RUnlock()
+	if info, exists := providers[key]; exists {
+		return *info, true
+	}
+	return providerInfo{}, false
+}
+
+// describeHop describes the provider of key as
+// "factory (file:line) -> result type [scope], registered at file:line"
+func describeHop(key uintptr) string {
+	var b strings.Builder
+	b.WriteString(keyLabel(key))
+	if source := factorySource(key); source != "" {
+		fmt.Fprintf(&b, " (%s)", source)
+	}
+
+	info, known := providerOf(key)
+	result := info.result
+	if result == nil {
+		mu.RLock()
+		result = types[key]
+		mu.RUnlock()
+	}
+	if result != nil {
+		fmt.Fprintf(&b, " -> %v", result)
+	} else {
+		b.WriteString(" -> ?")
+	}
+	if known {
+		fmt.Fprintf(&b, " [%s]", info.scope)
+		if info.site != "" {
+			fmt.Fprintf(&b, ", registered at %s", info.site)
+		}
+	}
+	return b.String()
+}
+
+// factorySource returns the file:line of the factory of key, or an empty string
+func factorySource(key uintptr) string {
+	fn := factoryOf(key)
+	if fn == nil {
+		return ""
+	}
+	file, line := fn.FileLine(fn.Entry())
+	return fmt.Sprintf("%s:%d", filepath.Base(file), line)
+}
+
+// missingDependency builds the report for a dependency of type t that could not be
+// found. subject names what needed it, such as "parameter db", and owner is the
+// function it belongs to, or zero.
+func missingDependency(subject string, t reflect.Type, owner uintptr) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "no dependency found for %s of type %v", subject, t)
+	if owner != 0 {
+		fmt.Fprintf(&b, " in %s", keyLabel(owner))
+		if source := factorySource(owner); source != "" {
+			fmt.Fprintf(&b, " (%s)", source)
+		}
+	}
+
+	if path := getCurrentResolutionPath(); len(path) > 0 {
+		labels := make([]string, len(path))
+		for i, key := range path {
+			labels[i] = keyLabel(key)
+		}
+		fmt.Fprintf(&b, "\n\tresolution path: %s", strings.Join(labels, " -> "))
+	}
+
+	for _, suggestion := range suggestionsFor(t) {
+		fmt.Fprintf(&b, "\n\tdid you mean %s?", suggestion)
+	}
+	return b.String()
+}
+
+// suggestion is a candidate for a missing dependency
+type suggestion struct {
+	text     string
+	distance int
+}
+
+// suggestionsFor returns up to three known providers or registrations whose type is
+// close to t: the same type built by a provider that has not been resolved yet, a
+// type assignable to t, or a type whose name is a near miss.
+func suggestionsFor(t reflect.Type) []string {
+	var candidates []suggestion
+	seen := make(map[string]bool)
+	add := func(candidate reflect.Type, origin string) {
+		if candidate == nil {
+			return
+		}
+		distance, close := typeDistance(t, candidate)
+		if !close {
+			return
+		}
+		text := fmt.Sprintf("%v (%s)", candidate, origin)
+		if seen[text] {
+			return
+		}
+		seen[text] = true
+		candidates = append(candidates, suggestion{text: text, distance: distance})
+	}
+
+	providersMutex.RLock()
+	for key, info := range providers {
+		origin := "provided by " + keyLabel(key)
+		if info.site != "" {
+			origin += ", registered at " + info.site
+		}
+		add(info.result, origin)
+	}
+	providersMutex.RUnlock()
+
+	typeRegistryMutex.RLock()
+	for _, instance := range typeRegistry {
+		add(reflect.TypeOf(instance), "registered with RegisterInstance")
+	}
+	typeRegistryMutex.RUnlock()
+
+	directMutex.RLock()
+	for _, instance := range directInstances {
+		add(reflect.TypeOf(instance), "registered with RegisterType")
+	}
+	directMutex.RUnlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].text < candidates[j].text
+	})
+
+	var result []string
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		result = append(result, candidates[i].text)
+	}
+	return result
+}
+
+// typeDistance tells how close candidate is to the wanted type t, and whether it is
+// close enough to be suggested
+func typeDistance(t, candidate reflect.Type) (int, bool) {
+	if candidate == t || candidate.AssignableTo(t) {
+		return 0, true
+	}
+
+	wanted, got := bareTypeName(t), bareTypeName(candidate)
+	if wanted == got {
+		// Same name behind a pointer or in another package
+		return 1, true
+	}
+	distance := editDistance(wanted, got)
+	limit := len(wanted) / 4
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > 3 {
+		limit = 3
+	}
+	return distance + 1, distance <= limit
+}
+
+// bareTypeName returns the lower-cased type name without pointers, slices and package
+func bareTypeName(t reflect.Type) string {
+	name := strings.TrimLeft(t.String(), "*[]")
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+	return strings.ToLower(name)
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// resetProviders forgets the providers seen so far
+func resetProviders() {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+	providers = make(map[uintptr]*providerInfo)
+}
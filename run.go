@@ -0,0 +1,132 @@
+package gioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// defaultShutdownTimeout bounds the shutdown phase of Run
+const defaultShutdownTimeout = 30 * time.Second
+
+// runOptions holds the settings of Run
+type runOptions struct {
+	shutdownTimeout time.Duration
+	signals         []os.Signal
+	returnError     bool
+}
+
+// RunOption is a function that modifies the settings of Run
+type RunOption func(*runOptions)
+
+// exit terminates the process, replaced in tests
+var exit = os.Exit
+
+// WithShutdownTimeout bounds the time Run spends stopping and disposing components.
+// The default is 30 seconds.
+func WithShutdownTimeout(timeout time.Duration) RunOption {
+	return func(o *runOptions) {
+		o.shutdownTimeout = timeout
+	}
+}
+
+// WithSignals sets the signals that make Run shut down. The default is SIGINT and SIGTERM.
+func WithSignals(signals ...os.Signal) RunOption {
+	return func(o *runOptions) {
+		o.signals = signals
+	}
+}
+
+// WithReturnError makes Run return the error instead of logging it and exiting the
+// process with status 1, for callers that handle the error themselves.
+func WithReturnError() RunOption {
+	return func(o *runOptions) {
+		o.returnError = true
+	}
+}
+
+// Run resolves root as a singleton, eagerly building its dependency graph, runs the
+// start hooks with Start and blocks until one of the shutdown signals arrives or ctx
+// is done. It then calls Shutdown with a bounded deadline, stopping and disposing the
+// components in reverse dependency order.
+//
+// The signals are watched from the start: a signal arriving while root is resolved or
+// started cancels the resolution context seen by factories and the context passed to
+// the start hooks, and Run shuts down what was built so far.
+//
+// Run is meant to be the last call of main, so by default it logs the errors raised
+// while resolving, starting or shutting down and exits the process with status 1,
+// letting supervisors see the failure. WithReturnError returns them instead.
+//
+// Example:
+//
+//	func main() {
+//	    gioc.Run(context.Background(), NewServer)
+//	}
+func Run[T any](ctx context.Context, root func() T, opts ...RunOption) error {
+	options := &runOptions{
+		shutdownTimeout: defaultShutdownTimeout,
+		signals:         []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	err := run(ctx, root, options)
+	if err != nil && !options.returnError {
+		log.Printf("gioc: %v", err)
+		exit(1)
+	}
+	return err
+}
+
+// run implements Run without the exit handling
+func run[T any](ctx context.Context, root func() T, options *runOptions) error {
+	signalCtx, stop := signal.NotifyContext(ctx, options.signals...)
+	defer stop()
+
+	err := resolveRoot(signalCtx, root)
+	if err == nil && signalCtx.Err() == nil {
+		err = Start(signalCtx)
+	}
+	if err != nil {
+		// A startup cut short by a signal is a requested shutdown, not a failure
+		if cause := signalCtx.Err(); cause != nil && errors.Is(err, cause) {
+			err = nil
+		}
+		return errors.Join(err, shutdownWithin(options.shutdownTimeout))
+	}
+
+	<-signalCtx.Done()
+	stop()
+
+	return shutdownWithin(options.shutdownTimeout)
+}
+
+// resolveRoot resolves root with IOC and ctx as the resolution context, converting a
+// panic to an error
+func resolveRoot[T any](ctx context.Context, root func() T) (err error) {
+	defer enterResolutionContext(ctx)()
+	currentPath := getCurrentResolutionPath()
+	defer func() {
+		if r := recover(); r != nil {
+			updateResolutionPath(currentPath)
+			err = fmt.Errorf("resolve %s: %v", funcName(root), r)
+		}
+	}()
+
+	IOC(root)
+	return nil
+}
+
+// shutdownWithin calls Shutdown with a fresh context bounded by timeout
+func shutdownWithin(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return Shutdown(ctx)
+}
@@ -5,6 +5,18 @@ import "sync"
 // Scope represents the lifetime of a component in the IoC container
 type Scope int
 
+// String returns the name of the scope
+func (s Scope) String() string {
+	switch s {
+	case Transient:
+		return "Transient"
+	case Scoped:
+		return "Scoped"
+	default:
+		return "Singleton"
+	}
+}
+
 // ScopeID represents a unique identifier for a scope
 type ScopeID string
 
@@ -26,14 +38,37 @@ func (s *ScopeContext) Get(key uintptr) (any, bool) {
 // Set stores an instance in the scope context
 func (s *ScopeContext) Set(key uintptr, instance any) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	_, replaced := s.instances[key]
 	s.instances[key] = instance
+	s.mu.Unlock()
+
+	if !replaced {
+		retainScopedKey(key)
+	}
+}
+
+// Delete removes an instance from the scope context
+func (s *ScopeContext) Delete(key uintptr) {
+	s.mu.Lock()
+	_, existed := s.instances[key]
+	delete(s.instances, key)
+	s.mu.Unlock()
+
+	if existed {
+		releaseScopedKey(key)
+	}
 }
 
 // Cleanup removes all instances from the scope context
 func (s *ScopeContext) Cleanup() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	released := s.instances
 	// Create a new map to avoid any race conditions with existing references
 	s.instances = make(map[uintptr]any)
+	s.mu.Unlock()
+
+	// Free the identifiers of the IOCFor instances held only by this scope
+	for key := range released {
+		releaseScopedKey(key)
+	}
 }
@@ -0,0 +1,170 @@
+package gioc
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// postInjectors holds the post-construction injections registered per factory
+	postInjectors      = make(map[uintptr][]postInjector)
+	postInjectorsMutex sync.RWMutex
+	// postInjectorCount lets the resolution path skip the lookup when nothing is registered
+	postInjectorCount atomic.Int64
+
+	// pendingInjections holds the injections waiting for the outermost resolution of
+	// each goroutine to finish
+	pendingInjections = sync.Map{} // map[goroutineID][]func()
+)
+
+// postInjector runs a post-construction injection on an instance cached under key
+type postInjector func(key uintptr, instance any)
+
+// PostInject registers inject to run on every instance built by fn after the instance
+// has been cached. This breaks dependency cycles that cannot be expressed through
+// constructors alone, such as an event bus that needs its subscribers while the
+// subscribers need the bus.
+//
+// Construction happens in two phases: fn builds the instance and the container caches
+// it, then inject runs once the outermost IOC call on the goroutine has finished, so
+// any component it resolves can depend on the instance. Cycles between factories
+// themselves are still rejected. Injections apply to Singleton and Scoped instances
+// resolved through IOC and IOCKey; use PostInjectFor for IOCFor factories. Other
+// goroutines may observe the cached instance before inject has run.
+//
+// Example:
+//
+//	func NewEventBus() *EventBus {
+//	    return &EventBus{}
+//	}
+//
+//	func NewAuditSubscriber() *AuditSubscriber {
+//	    return &AuditSubscriber{bus: gioc.IOC(NewEventBus)}
+//	}
+//
+//	gioc.PostInject(NewEventBus, func(bus *EventBus) {
+//	    bus.Subscribe(gioc.IOC(NewAuditSubscriber))
+//	})
+func PostInject[T any](fn func() T, inject func(T)) {
+	// Initialize the container if not already initialized
+	once.Do(initializeContainer)
+
+	fnPtr := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Entry()
+	addPostInjector(fnPtr, func(_ uintptr, instance any) {
+		typed, ok := instance.(T)
+		if !ok {
+			panic(fmt.Sprintf("type assertion failed in PostInject: expected %T, got %T for function %s",
+				*new(T), instance, runtime.FuncForPC(fnPtr).Name()))
+		}
+		inject(typed)
+	})
+}
+
+// PostInjectFor registers inject to run on every instance built by the IOCFor factory
+// fn, as PostInject does for IOC. inject receives the key the instance was built for.
+//
+// Example:
+//
+//	gioc.PostInjectFor(NewTenantCache, func(tenant string, cache *TenantCache) {
+//	    cache.Warm(gioc.IOCFor(NewTenantStore, tenant))
+//	})
+func PostInjectFor[K comparable, T any](fn func(K) T, inject func(K, T)) {
+	// Initialize the container if not already initialized
+	once.Do(initializeContainer)
+
+	fnPtr := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Entry()
+	addPostInjector(fnPtr, func(key uintptr, instance any) {
+		name, _ := keyName(key).(argKey)
+		arg, argOK := name.arg.(K)
+		typed, ok := instance.(T)
+		if !ok || !argOK {
+			panic(fmt.Sprintf("type assertion failed in PostInjectFor: expected %T, got %T for function %s",
+				*new(T), instance, runtime.FuncForPC(fnPtr).Name()))
+		}
+		inject(arg, typed)
+	})
+}
+
+// addPostInjector registers inject for the instances built by the factory at fnPtr
+func addPostInjector(fnPtr uintptr, inject postInjector) {
+	postInjectorsMutex.Lock()
+	defer postInjectorsMutex.Unlock()
+
+	postInjectors[fnPtr] = append(postInjectors[fnPtr], inject)
+	postInjectorCount.Add(1)
+}
+
+// PostInjectFields registers a post-construction injection that fills the fields of
+// instances built by fn that are tagged with `gioc:"inject"`, as InjectFields does.
+// The deps factories are resolved as singletons for fields of their result type.
+//
+// Example:
+//
+//	type EventBus struct {
+//	    Audit *AuditSubscriber `gioc:"inject"`
+//	}
+//
+//	gioc.PostInjectFields(NewEventBus, NewAuditSubscriber)
+func PostInjectFields[T any](fn func() T, deps ...interface{}) {
+	PostInject(fn, func(instance T) {
+		InjectFields(instance, deps...)
+	})
+}
+
+// queuePostInjections schedules the injections registered for the factory of key on a
+// new instance
+func queuePostInjections(key uintptr, instance any) {
+	if postInjectorCount.Load() == 0 {
+		return
+	}
+
+	fnPtr := factoryEntry(key)
+	postInjectorsMutex.RLock()
+	injectors := postInjectors[fnPtr]
+	postInjectorsMutex.RUnlock()
+	if len(injectors) == 0 {
+		return
+	}
+
+	gid := getGoroutineID()
+	var queue []func()
+	if pending, ok := pendingInjections.Load(gid); ok {
+		queue = pending.([]func())
+	}
+	for _, inject := range injectors {
+		inject := inject
+		queue = append(queue, func() { inject(key, instance) })
+	}
+	pendingInjections.Store(gid, queue)
+}
+
+// runPostInjections runs the injections queued on the current goroutine. It is called
+// when the outermost resolution finishes, so all queued instances are cached by then.
+func runPostInjections() {
+	if postInjectorCount.Load() == 0 {
+		return
+	}
+
+	gid := getGoroutineID()
+	for {
+		pending, ok := pendingInjections.LoadAndDelete(gid)
+		if !ok {
+			return
+		}
+		for _, inject := range pending.([]func()) {
+			inject()
+		}
+	}
+}
+
+// discardPostInjections drops the injections still queued on the current goroutine, so
+// injections queued by a resolution that panicked do not run during the next one
+func discardPostInjections() {
+	if postInjectorCount.Load() == 0 {
+		return
+	}
+	pendingInjections.Delete(getGoroutineID())
+}
@@ -0,0 +1,214 @@
+package gioc
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"runtime"
+	"slices"
+	"sync/atomic"
+)
+
+// ContainerSnapshot is a copy of the container state taken by Snapshot
+type ContainerSnapshot struct {
+	instances       map[uintptr]any
+	types           map[uintptr]reflect.Type
+	scopes          map[uintptr]Scope
+	instanceInfos   map[uintptr]instanceInfo
+	dependencyGraph map[uintptr]map[uintptr]bool
+	creationOrder   []uintptr
+
+	typeRegistry    map[string]any
+	directInstances map[string]interface{}
+
+	keyIDs       map[any]uintptr
+	keyNames     map[uintptr]any
+	keyFactories map[uintptr]uintptr
+
+	providers      map[uintptr]providerInfo
+	resolutions    map[uintptr]int64
+	postInjectors  map[uintptr][]postInjector
+	lifecycleHooks []lifecycleHook
+}
+
+// Snapshot copies the cached singletons, the instances added with RegisterInstance and
+// RegisterType, the keys of IOCKey and IOCFor, the providers declared with Register,
+// the resolution counts, the PostInject registrations and the lifecycle hooks. The instances themselves are
+// shared with the container, not copied.
+//
+// Example:
+//
+//	gioc.IOC(NewExpensiveGraph)
+//	snap := gioc.Snapshot()
+//
+//	for _, tc := range cases {
+//	    t.Run(tc.name, func(t *testing.T) {
+//	        defer gioc.Restore(snap)
+//	        // each case starts from the same graph
+//	    })
+//	}
+func Snapshot() *ContainerSnapshot {
+	once.Do(initializeContainer)
+	snap := &ContainerSnapshot{}
+
+	mu.RLock()
+	snap.instances = maps.Clone(instances)
+	snap.types = maps.Clone(types)
+	snap.scopes = maps.Clone(scopes)
+	snap.instanceInfos = maps.Clone(instanceInfos)
+	snap.dependencyGraph = cloneGraph(dependencyGraph)
+	snap.creationOrder = slices.Clone(creationOrder)
+	mu.RUnlock()
+
+	typeRegistryMutex.RLock()
+	snap.typeRegistry = maps.Clone(typeRegistry)
+	typeRegistryMutex.RUnlock()
+
+	directMutex.RLock()
+	snap.directInstances = maps.Clone(directInstances)
+	directMutex.RUnlock()
+
+	keyMutex.RLock()
+	snap.keyIDs = maps.Clone(keyIDs)
+	snap.keyNames = maps.Clone(keyNames)
+	snap.keyFactories = maps.Clone(keyFactories)
+	keyMutex.RUnlock()
+
+	providersMutex.RLock()
+	snap.providers = make(map[uintptr]providerInfo, len(providers))
+	for key, info := range providers {
+		snap.providers[key] = *info
+	}
+	providersMutex.RUnlock()
+
+	snap.resolutions = make(map[uintptr]int64)
+	resolutionCounts.Range(func(key, counter any) bool {
+		snap.resolutions[key.(uintptr)] = counter.(*atomic.Int64).Load()
+		return true
+	})
+
+	postInjectorsMutex.RLock()
+	snap.postInjectors = make(map[uintptr][]postInjector, len(postInjectors))
+	for key, injectors := range postInjectors {
+		snap.postInjectors[key] = slices.Clone(injectors)
+	}
+	postInjectorsMutex.RUnlock()
+
+	// Hooks are copied by value so their started flags are restored too
+	lifecycleRunMutex.Lock()
+	lifecycleMutex.Lock()
+	snap.lifecycleHooks = make([]lifecycleHook, len(lifecycleHooks))
+	for i, hook := range lifecycleHooks {
+		snap.lifecycleHooks[i] = *hook
+	}
+	lifecycleMutex.Unlock()
+	lifecycleRunMutex.Unlock()
+
+	return snap
+}
+
+// Restore puts the container back into the state copied by Snapshot. Singletons shared
+// with the snapshot are kept as they are, and singletons created since the snapshot are
+// disposed in reverse creation order; disposal errors are returned joined together.
+// The active scope and overrides are left alone, and the snapshot can be restored any
+// number of times.
+//
+// Example:
+//
+//	snap := gioc.Snapshot()
+//	defer gioc.Restore(snap)
+func Restore(snap *ContainerSnapshot) error {
+	once.Do(initializeContainer)
+
+	mu.Lock()
+	order := creationOrder
+	live := instances
+	instances = maps.Clone(snap.instances)
+	types = maps.Clone(snap.types)
+	scopes = maps.Clone(snap.scopes)
+	instanceInfos = maps.Clone(snap.instanceInfos)
+	dependencyGraph = cloneGraph(snap.dependencyGraph)
+	creationOrder = slices.Clone(snap.creationOrder)
+	mu.Unlock()
+
+	typeRegistryMutex.Lock()
+	typeRegistry = maps.Clone(snap.typeRegistry)
+	typeRegistryMutex.Unlock()
+
+	directMutex.Lock()
+	directInstances = maps.Clone(snap.directInstances)
+	directMutex.Unlock()
+
+	keyMutex.Lock()
+	keyIDs = maps.Clone(snap.keyIDs)
+	keyNames = maps.Clone(snap.keyNames)
+	keyFactories = maps.Clone(snap.keyFactories)
+	keyMutex.Unlock()
+
+	providersMutex.Lock()
+	providers = make(map[uintptr]*providerInfo, len(snap.providers))
+	for key, info := range snap.providers {
+		providers[key] = &info
+	}
+	providersMutex.Unlock()
+
+	resolutionCounts.Clear()
+	for key, resolutions := range snap.resolutions {
+		counter := new(atomic.Int64)
+		counter.Store(resolutions)
+		resolutionCounts.Store(key, counter)
+	}
+
+	postInjectorsMutex.Lock()
+	postInjectors = make(map[uintptr][]postInjector, len(snap.postInjectors))
+	count := 0
+	for key, injectors := range snap.postInjectors {
+		postInjectors[key] = slices.Clone(injectors)
+		count += len(injectors)
+	}
+	postInjectorCount.Store(int64(count))
+	postInjectorsMutex.Unlock()
+
+	lifecycleRunMutex.Lock()
+	lifecycleMutex.Lock()
+	lifecycleHooks = make([]*lifecycleHook, 0, len(snap.lifecycleHooks))
+	for _, hook := range snap.lifecycleHooks {
+		// Hooks of singletons missing from the snapshot would restart disposed instances
+		if _, exists := snap.instances[hook.key]; hook.key != 0 && !exists {
+			continue
+		}
+		lifecycleHooks = append(lifecycleHooks, &hook)
+	}
+	lifecycleMutex.Unlock()
+	lifecycleRunMutex.Unlock()
+
+	// The restored graph has to be validated again before production mode applies
+	validated.Store(false)
+	fastPath.Store(false)
+
+	// Dispose the singletons created since the snapshot, dependents first
+	var errs []error
+	for i := len(order) - 1; i >= 0; i-- {
+		key := order[i]
+		instance, exists := live[key]
+		if !exists || snap.instances[key] == instance {
+			continue
+		}
+		delete(live, key)
+		runtime.SetFinalizer(instance, nil)
+		if err := disposeInstance(key, instance); err != nil {
+			errs = append(errs, fmt.Errorf("dispose %T: %w", instance, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cloneGraph returns a deep copy of a dependency graph
+func cloneGraph(graph map[uintptr]map[uintptr]bool) map[uintptr]map[uintptr]bool {
+	clone := make(map[uintptr]map[uintptr]bool, len(graph))
+	for parent, children := range graph {
+		clone[parent] = maps.Clone(children)
+	}
+	return clone
+}
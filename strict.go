@@ -0,0 +1,94 @@
+package gioc
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// strictMode mirrors containerConfig.strictMode for the hot path
+var strictMode atomic.Bool
+
+// WithStrictMode enables or disables strict mode, which turns implicit behaviour into
+// panics:
+//
+//   - IOC, DirectIOC, IOCKey, IOCFor, IOCCtx, IOCCtxErr and the factories resolved for
+//     InjectConstructor, InjectFields and Validate only accept providers declared with
+//     Register; factories passed with WithDependency are explicit bindings and are
+//     called without being registered
+//   - Scoped resolutions, including DirectIOC, outside of a scope panic instead of
+//     behaving like Transient
+//   - RegisterInstance and RegisterType reject a type key that is already registered
+//   - InjectConstructor, InjectFields and injected Lazy and Provider handles reject
+//     dependencies that are only matched by an instance or a factory of an assignable
+//     type; bind them with WithDependency or Register a provider of the exact type
+//
+// Example:
+//
+//	gioc.Configure(gioc.WithStrictMode(true))
+//	gioc.Register(NewDatabase)
+//	gioc.Register(NewUserService)
+//
+//	svc := gioc.IOC(NewUserService)
+func WithStrictMode(enabled bool) Option {
+	return func(c *containerConfig) {
+		c.strictMode = enabled
+	}
+}
+
+// requireActiveScope panics in strict mode when key is resolved as Scoped while no
+// scope is active
+func requireActiveScope(key uintptr) {
+	if strictMode.Load() && getCurrentScopeContext() == nil {
+		panic(fmt.Sprintf("strict mode: %s is Scoped but no scope is active", keyLabel(key)))
+	}
+}
+
+// requireRegistered panics in strict mode when the factory at fnPtr was not declared
+// with Register
+func requireRegistered(fnPtr uintptr) {
+	if err := checkRegistered(fnPtr); err != nil {
+		panic(err.Error())
+	}
+}
+
+// checkRegistered returns an error in strict mode when the factory at fnPtr was not
+// declared with Register
+func checkRegistered(fnPtr uintptr) error {
+	if !strictMode.Load() {
+		return nil
+	}
+
+	providersMutex.RLock()
+	info, known := providers[fnPtr]
+	registered := known && info.registered
+	providersMutex.RUnlock()
+	if !registered {
+		return fmt.Errorf("strict mode: %s is not registered, declare it with gioc.Register", keyLabel(fnPtr))
+	}
+	return nil
+}
+
+// typeCollision describes a registration of instanceType under typeKey in strict mode
+// when existing is already registered under that key
+func typeCollision(registration, typeKey string, existing any, instanceType reflect.Type) string {
+	if existingType := reflect.TypeOf(existing); existingType != instanceType {
+		named := existingType
+		for named.Kind() == reflect.Ptr {
+			named = named.Elem()
+		}
+		return fmt.Sprintf("strict mode: %s: type key %s is already used by %v from package %s",
+			registration, typeKey, existingType, named.PkgPath())
+	}
+	return fmt.Sprintf("strict mode: %s: an instance of %s is already registered", registration, typeKey)
+}
+
+// requireExactMatch panics in strict mode when subject, of type want, is only matched by
+// what, of the assignable type got
+func requireExactMatch(subject string, want reflect.Type, what string, got reflect.Type) {
+	if !strictMode.Load() || got == want {
+		return
+	}
+	panic(fmt.Sprintf("strict mode: %s of type %v is only matched by %s of %v, bind it explicitly",
+		subject, want, what, got))
+}
@@ -0,0 +1,279 @@
+package gioc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultSlowFactoryThreshold is the self time above which a factory is reported as slow
+const defaultSlowFactoryThreshold = time.Second
+
+// factoryTiming accumulates the construction times of one key
+type factoryTiming struct {
+	typ   reflect.Type
+	calls int
+	total time.Duration
+	self  time.Duration
+}
+
+var (
+	// factoryTimings holds the construction times per key
+	factoryTimings      = make(map[uintptr]*factoryTiming)
+	factoryTimingsMutex sync.Mutex
+
+	// factoryClocks holds, per goroutine, the time spent in nested factory calls for
+	// every factory call in progress
+	factoryClocks = sync.Map{} // map[goroutineID]*[]time.Duration
+
+	// startupDone is set once Start succeeds, ending the startup window in which every
+	// factory call is timed
+	startupDone atomic.Bool
+	// slowFactoryWatch is set when a slow factory threshold was configured explicitly,
+	// which keeps factories timed after startup
+	slowFactoryWatch atomic.Bool
+)
+
+// WithSlowFactoryThreshold sets how long a single factory call may take, excluding the
+// factories it resolves, before it is reported as slow. Slow factories are sent to
+// listeners as EventSlowFactory and logged as warnings. The default is one second;
+// a negative threshold disables the detection.
+//
+// Factories are only timed during startup, until Start succeeds, and while profiling is
+// enabled. Setting a positive threshold keeps timing every factory call afterwards.
+//
+// Example:
+//
+//	gioc.Configure(gioc.WithSlowFactoryThreshold(200 * time.Millisecond))
+func WithSlowFactoryThreshold(threshold time.Duration) Option {
+	return func(c *containerConfig) {
+		c.slowFactoryThreshold = threshold
+	}
+}
+
+// slowFactoryThreshold returns the active threshold, zero when detection is disabled
+func slowFactoryThreshold() time.Duration {
+	configMutex.RLock()
+	threshold := config.slowFactoryThreshold
+	configMutex.RUnlock()
+
+	switch {
+	case threshold < 0:
+		return 0
+	case threshold == 0:
+		return defaultSlowFactoryThreshold
+	default:
+		return threshold
+	}
+}
+
+// timingFactories reports whether factory calls are timed
+func timingFactories() bool {
+	return !startupDone.Load() || slowFactoryWatch.Load() || profiling.Load()
+}
+
+// timeFactory calls the factory of key, recording its total and self time and
+// reporting it when it is slow. Outside of startup it only calls the factory, unless
+// profiling or an explicit slow factory threshold asks for timings.
+func timeFactory[T any](key uintptr, componentScope Scope, call func() T) T {
+	if !timingFactories() {
+		return callFactory(key, componentScope, call)
+	}
+
+	gid := getGoroutineID()
+	value, _ := factoryClocks.LoadOrStore(gid, new([]time.Duration))
+	stack := value.(*[]time.Duration)
+	*stack = append(*stack, 0)
+
+	started := time.Now()
+	succeeded := false
+	var instance T
+	defer func() {
+		total := time.Since(started)
+		nested := (*stack)[len(*stack)-1]
+		*stack = (*stack)[:len(*stack)-1]
+		if len(*stack) > 0 {
+			(*stack)[len(*stack)-1] += total
+		} else {
+			factoryClocks.Delete(gid)
+		}
+		if succeeded {
+			recordFactoryTiming(key, componentScope, reflect.TypeOf(any(instance)), total, total-nested)
+		}
+	}()
+
+	if profiling.Load() {
+		instance = profileFactory(key, componentScope, func() T {
+			return callFactory(key, componentScope, call)
+		})
+	} else {
+		instance = callFactory(key, componentScope, call)
+	}
+	succeeded = true
+	return instance
+}
+
+// excludeWait adds time the current goroutine spent waiting for another goroutine to
+// build a singleton to the nested time of the factory in progress, so the wait is not
+// counted as its self time
+func excludeWait(gid int64, wait time.Duration) {
+	value, exists := factoryClocks.Load(gid)
+	if !exists {
+		return
+	}
+	stack := value.(*[]time.Duration)
+	if len(*stack) > 0 {
+		(*stack)[len(*stack)-1] += wait
+	}
+}
+
+// recordFactoryTiming stores the times of a factory call and reports slow factories
+func recordFactoryTiming(key uintptr, componentScope Scope, typ reflect.Type, total, self time.Duration) {
+	factoryTimingsMutex.Lock()
+	timing, exists := factoryTimings[key]
+	if !exists {
+		timing = &factoryTiming{}
+		factoryTimings[key] = timing
+	}
+	timing.typ = typ
+	timing.calls++
+	timing.total += total
+	timing.self += self
+	factoryTimingsMutex.Unlock()
+
+	threshold := slowFactoryThreshold()
+	if threshold == 0 || self < threshold {
+		return
+	}
+
+	name := keyLabel(key)
+	emit(Event{Kind: EventSlowFactory, Name: name, Type: typ, Scope: componentScope, Duration: self})
+	if containerLogger.Load() == nil {
+		warnf("factory %s took %v to build %v (threshold %v)", name, self, typ, threshold)
+	}
+}
+
+// ConstructionReport summarizes the time spent building components
+type ConstructionReport struct {
+	// Total is the summed self time of all factories
+	Total time.Duration
+	// Components lists every built component, slowest self time first
+	Components []ComponentTiming
+	// CriticalPath is the chain of dependencies with the largest summed self time,
+	// starting at the component that depends on the others
+	CriticalPath []ComponentTiming
+	// CriticalPathDuration is the summed self time of CriticalPath
+	CriticalPathDuration time.Duration
+}
+
+// String renders the report as text, listing the critical path first
+func (r ConstructionReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Construction time: %v\n", r.Total)
+	fmt.Fprintf(&b, "Critical path (%v):\n", r.CriticalPathDuration)
+	for i, timing := range r.CriticalPath {
+		fmt.Fprintf(&b, "  %s%s (%v) self %v, total %v\n", strings.Repeat("  ", i), timing.Name, timing.Type, timing.Self, timing.Duration)
+	}
+	fmt.Fprintln(&b, "Components:")
+	for _, timing := range r.Components {
+		fmt.Fprintf(&b, "  %s (%v) self %v, total %v\n", timing.Name, timing.Type, timing.Self, timing.Duration)
+	}
+	return b.String()
+}
+
+// StartupReport returns the construction times recorded for every factory called
+// through IOC, DirectIOC and InjectConstructor, together with the critical path: the
+// chain of dependencies in the dependency graph whose summed self times are the
+// largest, which bounds how fast startup can get by building components concurrently.
+//
+// The times of a factory called several times, such as a transient one, are summed and
+// counted in Calls. Recording stops once Start succeeds, unless profiling or an explicit
+// slow factory threshold is enabled, so the report describes startup.
+//
+// Example:
+//
+//	app := gioc.IOC(NewApplication)
+//	log.Print(gioc.StartupReport())
+func StartupReport() ConstructionReport {
+	factoryTimingsMutex.Lock()
+	timings := make(map[uintptr]ComponentTiming, len(factoryTimings))
+	for key, timing := range factoryTimings {
+		timings[key] = ComponentTiming{Name: keyLabel(key), Type: timing.typ, Calls: timing.calls, Duration: timing.total, Self: timing.self}
+	}
+	factoryTimingsMutex.Unlock()
+
+	mu.RLock()
+	graph := make(map[uintptr][]uintptr, len(dependencyGraph))
+	for parent, children := range dependencyGraph {
+		for child := range children {
+			graph[parent] = append(graph[parent], child)
+		}
+	}
+	mu.RUnlock()
+
+	var report ConstructionReport
+	for _, timing := range timings {
+		report.Total += timing.Self
+		report.Components = append(report.Components, timing)
+	}
+	sort.Slice(report.Components, func(i, j int) bool {
+		if report.Components[i].Self != report.Components[j].Self {
+			return report.Components[i].Self > report.Components[j].Self
+		}
+		return report.Components[i].Name < report.Components[j].Name
+	})
+
+	// Longest path by self time, memoized per key
+	longest := make(map[uintptr]time.Duration, len(timings))
+	next := make(map[uintptr]uintptr, len(timings))
+	visiting := make(map[uintptr]bool)
+	var walk func(key uintptr) time.Duration
+	walk = func(key uintptr) time.Duration {
+		if duration, done := longest[key]; done {
+			return duration
+		}
+		if visiting[key] {
+			return 0
+		}
+		visiting[key] = true
+		var best time.Duration
+		for _, child := range graph[key] {
+			if duration := walk(child); duration > best || (duration == best && next[key] == 0) {
+				best = duration
+				next[key] = child
+			}
+		}
+		visiting[key] = false
+		longest[key] = timings[key].Self + best
+		return longest[key]
+	}
+
+	var start uintptr
+	for key := range timings {
+		if duration := walk(key); duration > report.CriticalPathDuration ||
+			(duration == report.CriticalPathDuration && start != 0 && keyLabel(key) < keyLabel(start)) {
+			report.CriticalPathDuration = duration
+			start = key
+		}
+	}
+	onPath := make(map[uintptr]bool)
+	for key := start; key != 0 && !onPath[key]; key = next[key] {
+		onPath[key] = true
+		if timing, exists := timings[key]; exists {
+			report.CriticalPath = append(report.CriticalPath, timing)
+		}
+	}
+	return report
+}
+
+// resetFactoryTimings clears the recorded construction times
+func resetFactoryTimings() {
+	factoryTimingsMutex.Lock()
+	defer factoryTimingsMutex.Unlock()
+	factoryTimings = make(map[uintptr]*factoryTiming)
+	startupDone.Store(false)
+}
@@ -1,9 +1,11 @@
 package gioc
 
 import (
+	"context"
 	"reflect"
 	"regexp"
 	"sync"
+	"time"
 )
 
 const (
@@ -15,6 +17,33 @@ const (
 	Scoped
 )
 
+// Disposable is implemented by components that hold resources which must be released
+// when the container drops them. Components implementing io.Closer are disposed by
+// calling Close instead.
+type Disposable interface {
+	Dispose() error
+}
+
+// Initializer is implemented by components that finish their setup after construction.
+// Init is called right after the factory returns, before the instance is cached.
+type Initializer interface {
+	Init(ctx context.Context) error
+}
+
+// Validator is implemented by components that can check their own configuration.
+// Validate is called right after the factory returns and after Init.
+type Validator interface {
+	Validate() error
+}
+
+// instanceInfo holds construction details of a cached singleton
+type instanceInfo struct {
+	// createdAt is the time the instance was cached
+	createdAt time.Time
+	// duration is the time spent in the factory, including nested resolutions
+	duration time.Duration
+}
+
 // ConstructorOptions represents options for constructor injection
 type ConstructorOptions struct {
 	// Dependencies is a map of parameter names to their factory functions
@@ -32,9 +61,14 @@ var (
 	scopes    = make(map[uintptr]Scope, 16)
 	// Track dependency graph for cycle detection
 	dependencyGraph = make(map[uintptr]map[uintptr]bool, 16)
+	// Construction details of singletons, keyed like instances
+	instanceInfos = make(map[uintptr]instanceInfo, 16)
 	// Track current resolution path for cycle detection using goroutine-local storage
 	resolutionPathMap = sync.Map{}           // map[goroutineID][]uintptr
 	tempPathBuffer    = make([]string, 0, 8) // Reusable buffer for path strings
+	// Track the factories running on each goroutine, innermost last. Entries are
+	// removed by the factories themselves, so ClearInstances leaves them alone.
+	factoryFrameMap = sync.Map{} // map[goroutineID]*[]factoryFrame
 
 	// Precompiled regex for parameter name extraction
 	paramRegex = regexp.MustCompile(`func\s+\w+\s*\((.*?)\)`)
@@ -0,0 +1,383 @@
+package gioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// flight tracks a singleton being built by one goroutine
+type flight struct {
+	owner int64
+	done  chan struct{}
+}
+
+var (
+	// inFlight holds the singletons currently being built, keyed like instances
+	inFlight = make(map[uintptr]*flight)
+	// waitingOn records the key each goroutine is waiting for, to detect cycles
+	// spanning several goroutines
+	waitingOn   = make(map[int64]uintptr)
+	flightMutex sync.Mutex
+)
+
+// ComponentTiming reports how long a singleton took to build
+type ComponentTiming struct {
+	// Name is the factory name, or the key for keyed registrations
+	Name string
+	// Type is the type of the instance
+	Type reflect.Type
+	// Duration is the time spent in the factory, including nested resolutions
+	Duration time.Duration
+	// Self is the time spent in the factory, excluding the factories it resolved and the
+	// time spent waiting for other goroutines to build them
+	Self time.Duration
+	// Calls is the number of factory calls summed into Duration and Self
+	Calls int
+}
+
+// WithWarmupWorkers limits how many factories Warmup builds concurrently.
+// The default is runtime.GOMAXPROCS(0).
+func WithWarmupWorkers(workers int) Option {
+	return func(c *containerConfig) {
+		c.warmupWorkers = workers
+	}
+}
+
+// warmupNode is a factory Warmup builds on a worker of its own
+type warmupNode struct {
+	factory reflect.Value
+	// pending counts the dependencies of the node that are not built yet
+	pending int
+	// dependents lists the nodes waiting for this one
+	dependents []uintptr
+}
+
+// Warmup eagerly builds the given root factories as singletons, building at most
+// WithWarmupWorkers factories concurrently.
+//
+// The dependencies recorded in the dependency graph by earlier resolutions are built
+// first, each on its own worker as soon as its own dependencies are cached, so
+// independent subtrees of a single root are built in parallel. A dependency can be
+// scheduled this way when it is one of the roots or a Singleton provider declared with
+// Register; other factories are built by the dependent reaching them. Dependencies not
+// discovered yet are found as the factories resolve them and are built on the worker
+// of the factory that reaches them first. A singleton is only ever built by one
+// goroutine: dependents resolving it concurrently wait until it is cached, so every
+// dependency is built before its dependents.
+//
+// ctx is the resolution context while the factories are built, so they observe its
+// cancellation through ResolutionContext. Once ctx is done no further factories are
+// started, but factories already being built run until they return.
+//
+// Warmup returns the construction time of every singleton it built, in creation
+// order, along with the errors raised by the factories. The dependents of a factory
+// that failed are not built.
+//
+// Example:
+//
+//	gioc.Register(NewDatabase)
+//	gioc.Register(NewSearchIndex)
+//
+//	timings, err := gioc.Warmup(ctx, NewAPIServer)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, timing := range timings {
+//	    log.Printf("%s built in %v", timing.Name, timing.Duration)
+//	}
+func Warmup(ctx context.Context, roots ...interface{}) ([]ComponentTiming, error) {
+	// Initialize the container if not already initialized
+	once.Do(initializeContainer)
+
+	rootValues := make([]reflect.Value, 0, len(roots))
+	for _, root := range roots {
+		rootValue := reflect.ValueOf(root)
+		if !isFactory(rootValue) {
+			return nil, fmt.Errorf("warmup: root %T must be a function with no arguments returning one value", root)
+		}
+		rootValues = append(rootValues, rootValue)
+	}
+
+	configMutex.RLock()
+	workers := config.warmupWorkers
+	configMutex.RUnlock()
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	mu.RLock()
+	builtBefore := len(creationOrder)
+	mu.RUnlock()
+
+	nodes, ready := planWarmup(rootValues)
+
+	type result struct {
+		key uintptr
+		err error
+	}
+	var (
+		errs    []error
+		running int
+		// stopped is set once ctx is done, factories already running are awaited
+		stopped bool
+	)
+	results := make(chan result)
+	done := ctx.Done()
+
+	for len(ready) > 0 || running > 0 {
+		// Start as many ready factories as there are idle workers
+		for !stopped && running < workers && len(ready) > 0 {
+			if err := ctx.Err(); err != nil {
+				errs = append(errs, err)
+				stopped = true
+				break
+			}
+			key := ready[0]
+			ready = ready[1:]
+			running++
+			go func(key uintptr, factory reflect.Value) {
+				results <- result{key: key, err: warmupFactory(ctx, factory)}
+			}(key, nodes[key].factory)
+		}
+		if running == 0 {
+			break
+		}
+
+		select {
+		case finished := <-results:
+			running--
+			if finished.err != nil {
+				errs = append(errs, finished.err)
+				continue
+			}
+			// Release the dependents whose dependencies are now all cached
+			for _, dependent := range nodes[finished.key].dependents {
+				node := nodes[dependent]
+				node.pending--
+				if node.pending == 0 {
+					ready = append(ready, dependent)
+				}
+			}
+		case <-done:
+			if !stopped {
+				errs = append(errs, ctx.Err())
+				stopped = true
+			}
+			done = nil
+		}
+	}
+
+	return warmupTimings(builtBefore), errors.Join(errs...)
+}
+
+// planWarmup returns the nodes Warmup builds for roots, with the edges recorded in the
+// dependency graph between them, and the nodes ready to be built right away. Edges
+// through factories Warmup cannot call are followed to the schedulable nodes behind
+// them; singletons already cached and edges closing a cycle are left out.
+func planWarmup(roots []reflect.Value) (map[uintptr]*warmupNode, []uintptr) {
+	factories := make(map[uintptr]reflect.Value, len(roots))
+	providersMutex.RLock()
+	for key, info := range providers {
+		if info.registered && info.scope == Singleton && isFactory(info.factory) {
+			factories[key] = info.factory
+		}
+	}
+	providersMutex.RUnlock()
+
+	var rootKeys []uintptr
+	for _, rootValue := range roots {
+		key := runtime.FuncForPC(rootValue.Pointer()).Entry()
+		factories[key] = rootValue
+		rootKeys = append(rootKeys, key)
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	nodes := make(map[uintptr]*warmupNode)
+	// visiting marks the nodes on the current path, to drop edges closing a cycle
+	visiting := make(map[uintptr]bool)
+	var visit func(key uintptr)
+	visit = func(key uintptr) {
+		node := &warmupNode{factory: factories[key]}
+		nodes[key] = node
+		visiting[key] = true
+		for _, dep := range schedulableDeps(key, factories) {
+			if visiting[dep] {
+				continue
+			}
+			if _, known := nodes[dep]; !known {
+				visit(dep)
+			}
+			node.pending++
+			nodes[dep].dependents = append(nodes[dep].dependents, key)
+		}
+		visiting[key] = false
+	}
+	for _, key := range rootKeys {
+		if _, known := nodes[key]; !known {
+			visit(key)
+		}
+	}
+
+	var ready []uintptr
+	for key, node := range nodes {
+		if node.pending == 0 {
+			ready = append(ready, key)
+		}
+	}
+	return nodes, ready
+}
+
+// schedulableDeps returns the dependencies of key that Warmup can build itself and that
+// are not cached yet, looking through the dependencies of factories it cannot call.
+// mu must be held.
+func schedulableDeps(key uintptr, factories map[uintptr]reflect.Value) []uintptr {
+	var deps []uintptr
+	seen := map[uintptr]bool{key: true}
+	queue := []uintptr{key}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for child := range dependencyGraph[current] {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			if _, cached := instances[child]; cached {
+				continue
+			}
+			if _, schedulable := factories[child]; schedulable {
+				deps = append(deps, child)
+				continue
+			}
+			queue = append(queue, child)
+		}
+	}
+	return deps
+}
+
+// warmupFactory resolves factory on the current worker goroutine, with ctx as the
+// resolution context
+func warmupFactory(ctx context.Context, factory reflect.Value) (err error) {
+	// Worker goroutines end here, so drop their resolution path afterwards
+	defer releaseResolutionPath()
+	defer enterResolutionContext(ctx)()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("warmup %s: %v", runtime.FuncForPC(factory.Pointer()).Name(), r)
+		}
+	}()
+
+	resolveFactory(factory)
+	return nil
+}
+
+// warmupTimings returns the timings of the singletons created after the first skip ones
+func warmupTimings(skip int) []ComponentTiming {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var timings []ComponentTiming
+	if skip > len(creationOrder) {
+		return timings
+	}
+	for _, key := range creationOrder[skip:] {
+		instance, exists := instances[key]
+		if !exists {
+			continue
+		}
+		timing := ComponentTiming{
+			Name:     keyLabel(key),
+			Type:     reflect.TypeOf(instance),
+			Duration: instanceInfos[key].duration,
+		}
+		factoryTimingsMutex.Lock()
+		if recorded, exists := factoryTimings[key]; exists {
+			timing.Self = recorded.self
+			timing.Calls = recorded.calls
+		}
+		factoryTimingsMutex.Unlock()
+		timings = append(timings, timing)
+	}
+	return timings
+}
+
+// acquireFlight registers the current goroutine as the builder of key. If another
+// goroutine is already building it, acquireFlight waits for it to finish and reports
+// waited so the caller can look the instance up again. It panics when waiting would
+// deadlock because the builder is itself waiting for this goroutine.
+func acquireFlight(key uintptr) (release func(), waited bool) {
+	gid := getGoroutineID()
+
+	flightMutex.Lock()
+	if current, exists := inFlight[key]; exists && current.owner != gid {
+		if cycle := waitCycle(gid, key); cycle != nil {
+			flightMutex.Unlock()
+			emit(Event{Kind: EventCycleDetected, Name: keyLabel(key), Path: fmt.Sprintf("%v", cycle)})
+			panic(fmt.Sprintf("circular dependency detected across goroutines: %v", cycle))
+		}
+		waitingOn[gid] = key
+		flightMutex.Unlock()
+
+		started := time.Now()
+		<-current.done
+		excludeWait(gid, time.Since(started))
+
+		flightMutex.Lock()
+		delete(waitingOn, gid)
+		flightMutex.Unlock()
+		return nil, true
+	}
+
+	current := &flight{owner: gid, done: make(chan struct{})}
+	inFlight[key] = current
+	flightMutex.Unlock()
+
+	return func() {
+		flightMutex.Lock()
+		if inFlight[key] == current {
+			delete(inFlight, key)
+		}
+		flightMutex.Unlock()
+		close(current.done)
+	}, false
+}
+
+// waitCycle follows the chain of goroutines waiting for each other starting at the
+// builder of key. It returns the keys involved if the chain leads back to gid.
+// flightMutex must be held.
+func waitCycle(gid int64, key uintptr) []string {
+	chain := []string{keyLabel(key)}
+	for {
+		current, exists := inFlight[key]
+		if !exists {
+			return nil
+		}
+		if current.owner == gid {
+			return chain
+		}
+		next, waiting := waitingOn[current.owner]
+		if !waiting {
+			return nil
+		}
+		key = next
+		chain = append(chain, keyLabel(key))
+		if len(chain) > len(inFlight)+1 {
+			// A cycle not involving gid, its own goroutines will report it
+			return nil
+		}
+	}
+}
+
+// releaseResolutionPath drops the resolution path of the current goroutine
+func releaseResolutionPath() {
+	resolutionPathMutex.Lock()
+	defer resolutionPathMutex.Unlock()
+	resolutionPathMap.Delete(getGoroutineID())
+}